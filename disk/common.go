@@ -1,12 +1,30 @@
 package disk
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/smithy-go"
 	"github.com/evolidev/storage/fs"
+	"io"
+	iofs "io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
 	"strings"
+	"time"
 )
 
+var errInvalidRange = errors.New("invalid range")
+
+const defaultPageSize = 1000
+
 type Common struct {
 	disk fs.Disk
+	ctx  context.Context
 }
 
 func NewCommon(disk fs.Disk) *Common {
@@ -15,6 +33,22 @@ func NewCommon(disk fs.Disk) *Common {
 	}
 }
 
+func (c *Common) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+
+	return c.ctx
+}
+
+func (c *Common) Put(file string, content []byte, visibility fs.Visibility) error {
+	return c.disk.PutWithOptions(file, content, fs.PutOptions{Visibility: visibility})
+}
+
+func (c *Common) PutWithOptions(file string, content []byte, options fs.PutOptions) error {
+	return c.disk.PutStream(file, bytes.NewReader(content), options)
+}
+
 func (c *Common) Missing(file string) bool {
 	return !c.disk.Exists(file)
 }
@@ -28,7 +62,7 @@ func (c *Common) Prepend(file string, content []byte) error {
 
 	content = append(content, oldContent...)
 
-	return c.disk.Put(file, content, fs.PUBLIC)
+	return c.put(file, content)
 }
 
 func (c *Common) Append(file string, content []byte) error {
@@ -40,33 +74,149 @@ func (c *Common) Append(file string, content []byte) error {
 
 	content = append(oldContent, content...)
 
-	return c.disk.Put(file, content, fs.PUBLIC)
+	return c.put(file, content)
 }
 
+// copier is implemented by disks which copy and move files without
+// downloading them.
+type copier interface {
+	copy(source string, destination string) error
+	move(source string, destination string) error
+}
+
+// Copy copies the file within the disk. Copying a file onto itself leaves it
+// untouched.
 func (c *Common) Copy(source string, destination string) error {
-	content, err := c.disk.Get(source)
+	if samePath(source, destination) {
+		_, err := c.disk.Stat(source)
 
-	if err != nil {
 		return err
 	}
 
-	return c.disk.Put(destination, content, fs.PUBLIC)
+	if n, ok := c.disk.(copier); ok {
+		return n.copy(source, destination)
+	}
+
+	return Transfer(c.disk, source, c.disk, destination, fs.TransferOptions{})
 }
 
 func (c *Common) Move(source string, destination string) error {
-	content, err := c.disk.Get(source)
+	if samePath(source, destination) {
+		_, err := c.disk.Stat(source)
 
-	if err != nil {
 		return err
 	}
 
-	err = c.disk.Put(destination, content, fs.PUBLIC)
+	if n, ok := c.disk.(copier); ok {
+		return n.move(source, destination)
+	}
+
+	return Transfer(c.disk, source, c.disk, destination, fs.TransferOptions{Move: true})
+}
+
+// put rewrites file with content keeping its visibility, headers and metadata.
+func (c *Common) put(file string, content []byte) error {
+	a, err := c.disk.Stat(file)
 
 	if err != nil {
 		return err
 	}
 
-	return c.disk.Delete(source)
+	if a.Visibility == 0 {
+		a.Visibility, _ = c.disk.Visibility(file)
+	}
+
+	return c.disk.PutWithOptions(file, content, fs.PutOptions{
+		Visibility:         a.Visibility,
+		ContentType:        a.ContentType,
+		CacheControl:       a.CacheControl,
+		ContentDisposition: a.ContentDisposition,
+		ContentEncoding:    a.ContentEncoding,
+		Metadata:           a.Metadata,
+		StorageClass:       a.StorageClass,
+	})
+}
+
+func (c *Common) Attributes(file string) fs.Attributes {
+	a, _ := c.disk.Stat(file)
+
+	return a
+}
+
+func (c *Common) Files(dir string) []*fs.File {
+	files, err := c.disk.List(dir)
+
+	if err != nil {
+		return make([]*fs.File, 0)
+	}
+
+	return files
+}
+
+func (c *Common) Directories(dir string) []fs.Disk {
+	dirs, err := c.disk.ListDirectories(dir)
+
+	if err != nil {
+		return make([]fs.Disk, 0)
+	}
+
+	return dirs
+}
+
+// ListPage pages through the sorted entries of dir. The token is the name of the
+// last entry of the previous page.
+func (c *Common) ListPage(dir string, token string, limit int) (fs.Page, error) {
+	page := fs.Page{Files: make([]*fs.File, 0), Directories: make([]fs.Disk, 0)}
+	files, err := c.disk.List(dir)
+
+	if err != nil {
+		return page, err
+	}
+
+	dirs, err := c.disk.ListDirectories(dir)
+
+	if err != nil {
+		return page, err
+	}
+
+	entries := make(map[string]interface{}, len(files)+len(dirs))
+	names := make([]string, 0, len(files)+len(dirs))
+
+	for _, d := range dirs {
+		entries[path.Base(d.Cwd())] = d
+	}
+
+	for _, file := range files {
+		entries[file.Name()] = file
+	}
+
+	for name := range entries {
+		if name > token {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+
+	if len(names) > limit {
+		names = names[:limit]
+		page.Next = names[limit-1]
+	}
+
+	for _, name := range names {
+		switch entry := entries[name].(type) {
+		case *fs.File:
+			page.Files = append(page.Files, entry)
+		case fs.Disk:
+			page.Directories = append(page.Directories, entry)
+		}
+	}
+
+	return page, nil
 }
 
 func (c *Common) Size(file string) int64 {
@@ -78,27 +228,58 @@ func (c *Common) LastModified(file string) int64 {
 }
 
 func (c *Common) AllDirectories(dir string) []fs.Disk {
-	dirs := c.disk.Directories(dir)
-	r := dirs
+	r := make([]fs.Disk, 0)
 
-	for _, d := range dirs {
-		r = append(r, c.disk.Directories(d.Cwd())...)
-	}
+	c.disk.Walk(dir, func(entry fs.Entry) error {
+		if entry.IsDir() {
+			r = append(r, entry.Directory)
+		}
+
+		return nil
+	})
 
 	return r
 }
 
 func (c *Common) AllFiles(dir string) []*fs.File {
-	dirs := c.AllDirectories(dir)
-	r := c.disk.Files(dir)
+	r := make([]*fs.File, 0)
 
-	for _, d := range dirs {
-		r = append(r, c.disk.Files(d.Cwd())...)
-	}
+	c.disk.Walk(dir, func(entry fs.Entry) error {
+		if !entry.IsDir() {
+			r = append(r, entry.File)
+		}
+
+		return nil
+	})
 
 	return r
 }
 
+func (c *Common) Query(dir string) *fs.Query {
+	return fs.NewQuery(c.disk, dir)
+}
+
+func (c *Common) Iter(dir string) fs.Iterator {
+	return newIterator(c.disk, dir)
+}
+
+func (c *Common) Walk(dir string, fn fs.WalkFunc) error {
+	it := newIterator(c.disk, dir)
+	defer it.Close()
+
+	for it.Next() {
+		err := fn(it.Entry())
+
+		if err == fs.SkipDir {
+			it.skip()
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return it.Err()
+}
+
 func (c *Common) File(file string) *fs.File {
 	parts := strings.Split(file, "/")
 	name := parts[len(parts)-1]
@@ -106,3 +287,74 @@ func (c *Common) File(file string) *fs.File {
 
 	return fs.NewFile(c.disk, strings.Join(parts, "/"), name)
 }
+
+func unsupported(op string, path string) error {
+	return &fs.Error{Op: op, Path: path, Kind: fs.ErrUnsupported, Err: fs.ErrUnsupported}
+}
+
+// joinURL appends the escaped file path to base.
+func joinURL(base string, file string) string {
+	parts := strings.Split(strings.Trim(path.Clean("/"+file), "/"), "/")
+
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+
+	return strings.TrimRight(base, "/") + "/" + strings.Join(parts, "/")
+}
+
+func wrapError(op string, path string, err error) error {
+	var e *fs.Error
+
+	if err == nil || errors.As(err, &e) {
+		return err
+	}
+
+	return &fs.Error{Op: op, Path: path, Kind: errorKind(err), Err: err}
+}
+
+func errorKind(err error) error {
+	var apiErr smithy.APIError
+
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchKey", "NotFound", "NoSuchBucket":
+			return fs.ErrNotFound
+		case "AccessDenied", "Forbidden", "AllAccessDisabled":
+			return fs.ErrPermission
+		}
+	}
+
+	switch {
+	case errors.Is(err, iofs.ErrNotExist):
+		return fs.ErrNotFound
+	case errors.Is(err, iofs.ErrExist):
+		return fs.ErrExists
+	case errors.Is(err, iofs.ErrPermission):
+		return fs.ErrPermission
+	}
+
+	return nil
+}
+
+func detectContentType(name string, open func() (io.ReadCloser, error)) string {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+
+	r, err := open()
+
+	if err != nil {
+		return ""
+	}
+	defer r.Close()
+
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(r, buf)
+
+	return http.DetectContentType(buf[:n])
+}
+
+func fileETag(size int64, modified time.Time) string {
+	return fmt.Sprintf("\"%x-%x\"", modified.UnixNano(), size)
+}
@@ -3,6 +3,7 @@ package disk
 import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	iofs "io/fs"
 	"os"
 )
 
@@ -12,6 +13,13 @@ type LocalConfig struct {
 	PermModeDirectoryPublic  os.FileMode
 	PermModeDirectoryPrivate os.FileMode
 	Prefix                   string
+	// BaseURL is the public URL the files are served from.
+	BaseURL string
+	// SignKey is used to sign temporary URLs, see SignedHandler.
+	SignKey []byte
+	// MaxUploadSize limits the uploads to temporary upload URLs. Defaults to
+	// 5 GiB, the largest single upload S3 accepts.
+	MaxUploadSize int64
 }
 
 type S3Config struct {
@@ -23,9 +31,28 @@ type S3Config struct {
 	Bucket           string
 	Endpoint         string
 	EndpointResolver s3.EndpointResolver
+	Region           string
+	UsePathStyle     bool
 	Prefix           string
+	// BaseURL overrides the bucket URL, e.g. for a CDN in front of the bucket.
+	BaseURL string
+	// PartSize is the size of the parts used by PutStream. Streams exceeding it
+	// are uploaded with a multipart upload. Defaults to 5 MiB, which is also the
+	// smallest part S3 accepts, smaller parts fail with EntityTooSmall.
+	PartSize int64
+	// CopyPartSize is the size of the parts used by Copy and Move. Larger objects
+	// are copied part by part with UploadPartCopy. Defaults to 5 GiB.
+	CopyPartSize int64
 }
 
 type MemoryConfig struct {
+	Prefix        string
+	BaseURL       string
+	SignKey       []byte
+	MaxUploadSize int64
+}
+
+type FSConfig struct {
+	FS     iofs.FS
 	Prefix string
 }
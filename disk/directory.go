@@ -0,0 +1,147 @@
+package disk
+
+import (
+	"errors"
+	"github.com/evolidev/storage/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+func (c *Common) CopyDirectory(source string, destination string, options fs.DirectoryOptions) error {
+	return CopyDirectory(c.disk, source, c.disk, destination, options)
+}
+
+func (c *Common) MoveDirectory(source string, destination string, options fs.DirectoryOptions) error {
+	return MoveDirectory(c.disk, source, c.disk, destination, options)
+}
+
+// CopyDirectory copies a directory tree within one disk or between two disks.
+// It copies as many files as possible and returns all failures as fs.Errors.
+func CopyDirectory(source fs.Disk, sourceDir string, destination fs.Disk, destinationDir string, options fs.DirectoryOptions) error {
+	return transferDirectory(source, sourceDir, destination, destinationDir, options, false)
+}
+
+// MoveDirectory moves a directory tree like CopyDirectory. The source directory
+// is only deleted once every file has been moved.
+func MoveDirectory(source fs.Disk, sourceDir string, destination fs.Disk, destinationDir string, options fs.DirectoryOptions) error {
+	return transferDirectory(source, sourceDir, destination, destinationDir, options, true)
+}
+
+func transferDirectory(source fs.Disk, sourceDir string, destination fs.Disk, destinationDir string, options fs.DirectoryOptions, move bool) error {
+	op := "copy"
+
+	if move {
+		op = "move"
+	}
+
+	sourceDir, destinationDir = strings.Trim(sourceDir, "/"), strings.Trim(destinationDir, "/")
+	same := source == destination
+
+	if same && (sourceDir == "" || strings.HasPrefix(destinationDir+"/", sourceDir+"/")) {
+		return &fs.Error{Op: op, Path: destinationDir, Kind: fs.ErrExists, Err: errors.New("destination is inside the source directory")}
+	}
+
+	t := &transfer{
+		source:      source,
+		destination: destination,
+		same:        same,
+		move:        move,
+		op:          op,
+		overwrite:   options.Overwrite,
+	}
+
+	parallelism := options.Parallelism
+
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	jobs := make(chan [2]string)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				t.file(job[0], job[1])
+			}
+		}()
+	}
+
+	err := source.Walk(sourceDir, func(entry fs.Entry) error {
+		target := path.Join(destinationDir, strings.TrimPrefix(strings.TrimPrefix(entry.Path, sourceDir), "/"))
+
+		if entry.IsDir() {
+			t.fail(destination.MakeDirectory(target, 0))
+		} else {
+			jobs <- [2]string{entry.Path, target}
+		}
+
+		return nil
+	})
+
+	close(jobs)
+	wg.Wait()
+	t.fail(err)
+
+	if len(t.errors) > 0 {
+		return t.errors
+	}
+
+	if move && !t.skipped {
+		return source.DeleteDirectory(sourceDir)
+	}
+
+	return nil
+}
+
+type transfer struct {
+	source      fs.Disk
+	destination fs.Disk
+	same        bool
+	move        bool
+	op          string
+	overwrite   fs.Overwrite
+	mu          sync.Mutex
+	errors      fs.Errors
+	skipped     bool
+}
+
+func (t *transfer) file(source string, destination string) {
+	if t.overwrite != fs.OverwriteAlways && t.destination.Exists(destination) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		if t.overwrite == fs.OverwriteFail {
+			t.errors = append(t.errors, &fs.Error{Op: t.op, Path: destination, Kind: fs.ErrExists, Err: errors.New("file exists")})
+		}
+
+		t.skipped = true
+
+		return
+	}
+
+	switch {
+	case t.same && t.move:
+		t.fail(t.source.Move(source, destination))
+	case t.same:
+		t.fail(t.source.Copy(source, destination))
+	default:
+		t.fail(Transfer(t.source, source, t.destination, destination, fs.TransferOptions{Move: t.move}))
+	}
+}
+
+func (t *transfer) fail(err error) {
+	if err == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.errors = append(t.errors, err)
+}
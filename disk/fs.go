@@ -0,0 +1,261 @@
+package disk
+
+import (
+	"context"
+	"github.com/evolidev/storage/fs"
+	"io"
+	iofs "io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// FS is a read-only disk backed by an io/fs.FS like embed.FS or fstest.MapFS.
+type FS struct {
+	*Common
+	config FSConfig
+}
+
+func NewFS(config FSConfig) *FS {
+	disk := &FS{config: config}
+	disk.Common = NewCommon(disk)
+
+	return disk
+}
+
+func (f *FS) Prefix(prefix string) fs.Disk {
+	c := f.config
+	c.Prefix = prefix
+	d := NewFS(c)
+	d.ctx = f.ctx
+
+	return d
+}
+
+func (f *FS) Cwd() string {
+	return f.config.Prefix
+}
+
+func (f *FS) WithContext(ctx context.Context) fs.Disk {
+	c := *f
+	c.Common = &Common{disk: &c, ctx: ctx}
+
+	return &c
+}
+
+func (f *FS) PutStream(file string, content io.Reader, options fs.PutOptions) error {
+	return f.readOnly("put", file)
+}
+
+func (f *FS) Get(file string) ([]byte, error) {
+	content, err := iofs.ReadFile(f.config.FS, f.getPath(file))
+
+	if err != nil {
+		return nil, wrapError("get", file, err)
+	}
+
+	return content, nil
+}
+
+func (f *FS) ReadStream(file string) (io.ReadCloser, error) {
+	r, err := f.config.FS.Open(f.getPath(file))
+
+	if err != nil {
+		return nil, wrapError("read", file, err)
+	}
+
+	return r, nil
+}
+
+func (f *FS) GetRange(file string, offset int64, length int64) ([]byte, error) {
+	if offset < 0 || length < 0 {
+		return nil, wrapError("read", file, errInvalidRange)
+	}
+
+	r, err := f.config.FS.Open(f.getPath(file))
+
+	if err != nil {
+		return nil, wrapError("read", file, err)
+	}
+	defer r.Close()
+
+	if seeker, ok := r.(io.Seeker); ok {
+		_, err = seeker.Seek(offset, io.SeekStart)
+	} else {
+		_, err = io.CopyN(io.Discard, r, offset)
+	}
+
+	if err == io.EOF {
+		return []byte{}, nil
+	}
+
+	if err != nil {
+		return nil, wrapError("read", file, err)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(r, length))
+
+	if err != nil {
+		return nil, wrapError("read", file, err)
+	}
+
+	return content, nil
+}
+
+func (f *FS) Stat(file string) (fs.Attributes, error) {
+	stats, err := iofs.Stat(f.config.FS, f.getPath(file))
+
+	if err != nil {
+		return fs.Attributes{}, wrapError("stat", file, err)
+	}
+
+	a := fs.Attributes{
+		Size:         stats.Size(),
+		LastModified: stats.ModTime().Unix(),
+		ETag:         fileETag(stats.Size(), stats.ModTime()),
+		Visibility:   fs.PUBLIC,
+		IsDir:        stats.IsDir(),
+	}
+
+	if !a.IsDir {
+		a.ContentType = detectContentType(file, func() (io.ReadCloser, error) {
+			return f.config.FS.Open(f.getPath(file))
+		})
+	}
+
+	return a, nil
+}
+
+func (f *FS) Exists(file string) bool {
+	_, err := iofs.Stat(f.config.FS, f.getPath(file))
+
+	return err == nil
+}
+
+func (f *FS) Path(file string) string {
+	return f.getPath(file)
+}
+
+func (f *FS) URL(file string) (string, error) {
+	return "", unsupported("url", file)
+}
+
+func (f *FS) TemporaryURL(file string, expires time.Duration) (string, error) {
+	return "", unsupported("url", file)
+}
+
+func (f *FS) TemporaryUploadURL(file string, expires time.Duration, options fs.PutOptions) (string, error) {
+	return "", f.readOnly("put", file)
+}
+
+func (f *FS) Prepend(file string, content []byte) error {
+	return f.readOnly("prepend", file)
+}
+
+func (f *FS) Append(file string, content []byte) error {
+	return f.readOnly("append", file)
+}
+
+func (f *FS) Copy(source string, destination string) error {
+	return f.readOnly("copy", destination)
+}
+
+func (f *FS) Move(source string, destination string) error {
+	return f.readOnly("move", source)
+}
+
+func (f *FS) CopyDirectory(source string, destination string, options fs.DirectoryOptions) error {
+	return f.readOnly("copy", destination)
+}
+
+func (f *FS) MoveDirectory(source string, destination string, options fs.DirectoryOptions) error {
+	return f.readOnly("move", source)
+}
+
+func (f *FS) Delete(files ...string) error {
+	if len(files) == 0 {
+		return nil
+	}
+
+	return f.readOnly("delete", files[0])
+}
+
+func (f *FS) MakeDirectory(dir string, visibility fs.Visibility) error {
+	return f.readOnly("mkdir", dir)
+}
+
+func (f *FS) DeleteDirectory(dir string) error {
+	return f.readOnly("rmdir", dir)
+}
+
+func (f *FS) Visibility(file string) (fs.Visibility, error) {
+	if _, err := iofs.Stat(f.config.FS, f.getPath(file)); err != nil {
+		return 0, wrapError("visibility", file, err)
+	}
+
+	return fs.PUBLIC, nil
+}
+
+func (f *FS) SetVisibility(file string, visibility fs.Visibility) error {
+	return f.readOnly("chmod", file)
+}
+
+func (f *FS) List(dir string) ([]*fs.File, error) {
+	result := make([]*fs.File, 0)
+	entries, err := iofs.ReadDir(f.config.FS, f.getPath(dir))
+
+	if err != nil {
+		return nil, wrapError("list", dir, err)
+	}
+
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && !entry.IsDir() {
+			result = append(result, fs.NewFileWithAttributes(f, strings.Trim(path.Clean("/"+dir), "/"), entry.Name(), fs.Attributes{
+				Size:         info.Size(),
+				LastModified: info.ModTime().Unix(),
+				ETag:         fileETag(info.Size(), info.ModTime()),
+			}))
+		}
+	}
+
+	return result, nil
+}
+
+func (f *FS) ListDirectories(dir string) ([]fs.Disk, error) {
+	result := make([]fs.Disk, 0)
+	entries, err := iofs.ReadDir(f.config.FS, f.getPath(dir))
+
+	if err != nil {
+		return nil, wrapError("list", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			result = append(result, f.Prefix(path.Join(f.getDirectory(dir), entry.Name())))
+		}
+	}
+
+	return result, nil
+}
+
+func (f *FS) readOnly(op string, file string) error {
+	return &fs.Error{Op: op, Path: file, Kind: fs.ErrReadOnly, Err: fs.ErrReadOnly}
+}
+
+func (f *FS) getPath(file string) string {
+	file = strings.TrimPrefix(path.Join(f.config.Prefix, file), "/")
+
+	if file == "" {
+		return "."
+	}
+
+	return file
+}
+
+func (f *FS) getDirectory(dir string) string {
+	if f.getPath(dir) == "." {
+		return ""
+	}
+
+	return f.getPath(dir)
+}
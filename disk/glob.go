@@ -0,0 +1,81 @@
+package disk
+
+import (
+	"github.com/evolidev/storage/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+func (c *Common) Glob(pattern string) ([]*fs.File, error) {
+	pattern = strings.Trim(pattern, "/")
+	r := make([]*fs.File, 0)
+
+	if _, err := fs.Match(pattern, ""); err != nil {
+		return nil, wrapError("glob", pattern, err)
+	}
+
+	dir := path.Dir(literalPrefix(pattern) + "x")
+
+	if dir == "." {
+		dir = ""
+	}
+
+	err := c.disk.Walk(dir, func(entry fs.Entry) error {
+		if entry.IsDir() && !mayContain(pattern, entry.Path) {
+			return fs.SkipDir
+		}
+
+		if ok, _ := fs.Match(pattern, entry.Path); ok && !entry.IsDir() {
+			r = append(r, entry.File)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, wrapError("glob", pattern, err)
+	}
+
+	sortFiles(r)
+
+	return r, nil
+}
+
+// literalPrefix returns the part of pattern in front of the first meta character.
+func literalPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, "*?[\\"); i >= 0 {
+		return pattern[:i]
+	}
+
+	return pattern
+}
+
+// mayContain reports whether files below dir can match pattern.
+func mayContain(pattern string, dir string) bool {
+	segments := strings.Split(pattern, "/")
+
+	for _, name := range strings.Split(dir, "/") {
+		if len(segments) == 0 {
+			return false
+		}
+
+		if segments[0] == "**" {
+			return true
+		}
+
+		if ok, _ := path.Match(segments[0], name); !ok {
+			return false
+		}
+
+		segments = segments[1:]
+	}
+
+	return len(segments) > 0
+}
+
+func sortFiles(files []*fs.File) {
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path() < files[j].Path()
+	})
+}
@@ -0,0 +1,190 @@
+package disk
+
+import (
+	"github.com/evolidev/storage/fs"
+	"io"
+	"path"
+	"sort"
+)
+
+// batches yields the entries of one directory in batches and io.EOF at the end.
+type batches interface {
+	Next() ([]fs.Entry, error)
+	Close() error
+}
+
+// batchLister is implemented by disks with a cheaper way to read a directory
+// in batches than ListPage.
+type batchLister interface {
+	batches(dir string) (batches, error)
+}
+
+type frame struct {
+	dir     string
+	batches batches
+	entries []fs.Entry
+}
+
+// iterator walks depth first. Every directory on the current path keeps its open
+// batches, so memory is bounded by the depth of the tree and the batch size.
+type iterator struct {
+	disk  fs.Disk
+	stack []*frame
+	entry fs.Entry
+	err   error
+}
+
+func newIterator(disk fs.Disk, dir string) *iterator {
+	return &iterator{disk: disk, stack: []*frame{{dir: dir}}}
+}
+
+func (it *iterator) Next() bool {
+	for it.err == nil && len(it.stack) > 0 {
+		if err := it.disk.Context().Err(); err != nil {
+			it.err = err
+
+			break
+		}
+
+		top := it.stack[len(it.stack)-1]
+
+		if len(top.entries) == 0 && !it.fill(top) {
+			continue
+		}
+
+		it.entry = top.entries[0]
+		top.entries = top.entries[1:]
+
+		if it.entry.IsDir() {
+			it.stack = append(it.stack, &frame{dir: it.entry.Path})
+		}
+
+		return true
+	}
+
+	it.Close()
+
+	return false
+}
+
+// fill loads the next batch of f and pops f once it is exhausted.
+func (it *iterator) fill(f *frame) bool {
+	var err error
+
+	if f.batches == nil {
+		f.batches, err = it.open(f.dir)
+	}
+
+	if err == nil {
+		f.entries, err = f.batches.Next()
+	}
+
+	if err == io.EOF {
+		it.pop()
+
+		return false
+	}
+
+	if err != nil {
+		it.err = err
+
+		return false
+	}
+
+	return len(f.entries) > 0
+}
+
+func (it *iterator) open(dir string) (batches, error) {
+	if l, ok := it.disk.(batchLister); ok {
+		return l.batches(dir)
+	}
+
+	return &pageBatches{disk: it.disk, dir: dir}, nil
+}
+
+func (it *iterator) pop() {
+	top := it.stack[len(it.stack)-1]
+
+	if top.batches != nil {
+		top.batches.Close()
+	}
+
+	it.stack = it.stack[:len(it.stack)-1]
+}
+
+// skip skips the directory of the current entry, or the rest of its parent
+// directory if the current entry is a file.
+func (it *iterator) skip() {
+	if len(it.stack) > 0 {
+		it.pop()
+	}
+}
+
+func (it *iterator) Entry() fs.Entry {
+	return it.entry
+}
+
+func (it *iterator) Err() error {
+	return it.err
+}
+
+func (it *iterator) Close() error {
+	for len(it.stack) > 0 {
+		it.pop()
+	}
+
+	return nil
+}
+
+// pageBatches reads a directory page by page with ListPage. A directory can
+// show up on several pages, e.g. as S3 marker object and as common prefix.
+type pageBatches struct {
+	disk  fs.Disk
+	dir   string
+	token string
+	done  bool
+	seen  map[string]bool
+}
+
+func (p *pageBatches) Next() ([]fs.Entry, error) {
+	if p.done {
+		return nil, io.EOF
+	}
+
+	page, err := p.disk.ListPage(p.dir, p.token, defaultPageSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.token = page.Next
+	p.done = page.Next == ""
+	entries := make([]fs.Entry, 0, len(page.Files)+len(page.Directories))
+
+	if p.seen == nil {
+		p.seen = make(map[string]bool)
+	}
+
+	for _, d := range page.Directories {
+		name := path.Base(d.Cwd())
+
+		if !p.seen[name] {
+			p.seen[name] = true
+			entries = append(entries, fs.Entry{Path: path.Join(p.dir, name), Directory: d})
+		}
+	}
+
+	for _, file := range page.Files {
+		entries = append(entries, fs.Entry{Path: path.Join(p.dir, file.Name()), File: file})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	return entries, nil
+}
+
+func (p *pageBatches) Close() error {
+	return nil
+}
@@ -1,18 +1,46 @@
 package disk
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"github.com/evolidev/storage/fs"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 )
 
+// metadataSuffix marks the hidden sidecar files which hold the metadata
+// of a file that can not be stored in the file system itself.
+const metadataSuffix = ".meta.json"
+
 type Local struct {
 	*Common
 	config LocalConfig
 	prefix string
 }
 
+type localMetadata struct {
+	ContentType        string            `json:"content_type,omitempty"`
+	CacheControl       string            `json:"cache_control,omitempty"`
+	ContentDisposition string            `json:"content_disposition,omitempty"`
+	ContentEncoding    string            `json:"content_encoding,omitempty"`
+	Checksum           string            `json:"checksum,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	// Size and Modified tell whether the file changed since the checksum was taken.
+	Size     int64 `json:"size,omitempty"`
+	Modified int64 `json:"modified,omitempty"`
+}
+
 func NewLocal(config LocalConfig) *Local {
 	disk := &Local{}
 	disk.Common = NewCommon(disk)
@@ -41,54 +69,193 @@ func NewLocal(config LocalConfig) *Local {
 func (l *Local) Prefix(prefix string) fs.Disk {
 	c := l.config
 	c.Prefix = prefix
+	d := NewLocal(c)
+	d.ctx = l.ctx
 
-	return NewLocal(c)
+	return d
 }
 
 func (l *Local) Cwd() string {
 	return l.config.Prefix
 }
 
-func (l *Local) Put(file string, content []byte, visibility fs.Visibility) error {
-	directories := strings.Split(strings.Replace(file, l.config.Prefix, "", 1), "/")
-	directories = directories[:len(directories)-1]
-	dirPath := strings.Join(directories, "/")
-	if dirPath != "" {
-		err := l.MakeDirectory(l.getPath(dirPath), visibility)
+func (l *Local) WithContext(ctx context.Context) fs.Disk {
+	c := *l
+	c.Common = &Common{disk: &c, ctx: ctx}
 
-		if err != nil {
+	return &c
+}
+
+func (l *Local) PutStream(file string, content io.Reader, options fs.PutOptions) error {
+	return wrapError("put", file, l.put(file, content, options.Visibility, localMetadata{
+		ContentType:        options.ContentType,
+		CacheControl:       options.CacheControl,
+		ContentDisposition: options.ContentDisposition,
+		ContentEncoding:    options.ContentEncoding,
+		Metadata:           options.Metadata,
+	}))
+}
+
+// put writes content and its sidecar with the checksum of content, creating
+// the parent directories.
+func (l *Local) put(file string, content io.Reader, visibility fs.Visibility, metadata localMetadata) error {
+	err := l.makeParent(file, visibility)
+
+	hash := sha256.New()
+
+	if err == nil {
+		err = l.write(file, io.TeeReader(content, hash), visibility)
+	}
+
+	var stats os.FileInfo
+
+	if err == nil {
+		stats, err = os.Stat(l.getPath(file))
+	}
+
+	if err == nil {
+		metadata.Checksum = "sha256:" + hex.EncodeToString(hash.Sum(nil))
+		metadata.Size, metadata.Modified = stats.Size(), stats.ModTime().UnixNano()
+		err = l.writeMetadata(file, metadata)
+	}
+
+	return err
+}
+
+// copy streams the file through the checksum and keeps its visibility and sidecar.
+func (l *Local) copy(source string, destination string) error {
+	stats, err := os.Stat(l.getPath(source))
+
+	if err != nil {
+		return wrapError("copy", source, err)
+	}
+
+	content, err := os.Open(l.getPath(source))
+
+	if err != nil {
+		return wrapError("copy", source, err)
+	}
+	defer content.Close()
+
+	err = l.put(destination, content, l.visibility(stats), l.readMetadata(source))
+
+	return wrapError("copy", destination, err)
+}
+
+// move renames the file and falls back to copy and delete across file systems.
+func (l *Local) move(source string, destination string) error {
+	stats, err := os.Stat(l.getPath(source))
+
+	if err != nil {
+		return wrapError("move", source, err)
+	}
+
+	err = l.makeParent(destination, l.visibility(stats))
+
+	if err == nil {
+		err = os.Rename(l.getPath(source), l.getPath(destination))
+	}
+
+	if errors.Is(err, syscall.EXDEV) {
+		if err = l.copy(source, destination); err != nil {
 			return err
 		}
+
+		return l.Delete(source)
 	}
 
-	return l.write(file, content, visibility)
+	if err != nil {
+		return wrapError("move", source, err)
+	}
+
+	err = os.Rename(l.metadataPath(source), l.metadataPath(destination))
+
+	// a sidecar left over from an overwritten destination has to go
+	if os.IsNotExist(err) {
+		err = os.Remove(l.metadataPath(destination))
+	}
+
+	if os.IsNotExist(err) {
+		err = nil
+	}
+
+	return wrapError("move", source, err)
 }
 
 func (l *Local) Get(file string) ([]byte, error) {
 	f, err := os.ReadFile(l.getPath(file))
 
 	if err != nil {
-		return nil, err
+		return nil, wrapError("get", file, err)
 	}
 
 	return f, nil
 }
 
-func (l *Local) Attributes(file string) fs.Attributes {
-	var s, m int64
-	s = 0
-	m = 0
+func (l *Local) ReadStream(file string) (io.ReadCloser, error) {
+	f, err := os.Open(l.getPath(file))
+
+	if err != nil {
+		return nil, wrapError("read", file, err)
+	}
+
+	return f, nil
+}
+
+func (l *Local) GetRange(file string, offset int64, length int64) ([]byte, error) {
+	if offset < 0 || length < 0 {
+		return nil, wrapError("read", file, errInvalidRange)
+	}
+
+	f, err := os.Open(l.getPath(file))
+
+	if err != nil {
+		return nil, wrapError("read", file, err)
+	}
+	defer f.Close()
+
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return nil, wrapError("read", file, err)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(f, length))
+
+	if err != nil {
+		return nil, wrapError("read", file, err)
+	}
+
+	return content, nil
+}
+
+func (l *Local) Stat(file string) (fs.Attributes, error) {
 	stats, err := os.Stat(l.getPath(file))
 
-	if err == nil {
-		s = stats.Size()
-		m = stats.ModTime().Unix()
+	if err != nil {
+		return fs.Attributes{}, wrapError("stat", file, err)
 	}
 
-	return fs.Attributes{
-		Size:         s,
-		LastModified: m,
+	metadata := l.readMetadata(file)
+	a := fs.Attributes{
+		Size:               stats.Size(),
+		LastModified:       stats.ModTime().Unix(),
+		ContentType:        metadata.ContentType,
+		CacheControl:       metadata.CacheControl,
+		ContentDisposition: metadata.ContentDisposition,
+		ContentEncoding:    metadata.ContentEncoding,
+		ETag:               fileETag(stats.Size(), stats.ModTime()),
+		Checksum:           metadata.checksum(stats),
+		Visibility:         l.visibility(stats),
+		IsDir:              stats.IsDir(),
+		Metadata:           metadata.Metadata,
 	}
+
+	if a.ContentType == "" && !a.IsDir {
+		a.ContentType = detectContentType(file, func() (io.ReadCloser, error) {
+			return os.Open(l.getPath(file))
+		})
+	}
+
+	return a, nil
 }
 
 func (l *Local) Exists(file string) bool {
@@ -107,100 +274,370 @@ func (l *Local) Path(file string) string {
 	return p
 }
 
+func (l *Local) URL(file string) (string, error) {
+	if l.config.BaseURL == "" {
+		return "", unsupported("url", file)
+	}
+
+	return joinURL(l.config.BaseURL, l.getPath(file)), nil
+}
+
+func (l *Local) TemporaryURL(file string, expires time.Duration) (string, error) {
+	if l.config.BaseURL == "" || len(l.config.SignKey) == 0 {
+		return "", unsupported("url", file)
+	}
+
+	return signedURL(l.config.BaseURL, l.config.SignKey, http.MethodGet, file, expires, url.Values{}), nil
+}
+
+func (l *Local) TemporaryUploadURL(file string, expires time.Duration, options fs.PutOptions) (string, error) {
+	if l.config.BaseURL == "" || len(l.config.SignKey) == 0 {
+		return "", unsupported("url", file)
+	}
+
+	return signedURL(l.config.BaseURL, l.config.SignKey, http.MethodPut, file, expires, putParams(options, l.config.MaxUploadSize)), nil
+}
+
 func (l *Local) Delete(files ...string) error {
 	for _, file := range files {
 		err := os.Remove(l.getPath(file))
 		if err != nil {
-			return err
+			return wrapError("delete", file, err)
 		}
+
+		os.Remove(l.metadataPath(file))
 	}
 
 	return nil
 }
 
 func (l *Local) MakeDirectory(dir string, visibility fs.Visibility) error {
-	mode := l.config.PermModeDirectoryPublic
+	return wrapError("mkdir", dir, os.MkdirAll(l.getPath(dir), l.mode(visibility, true)))
+}
 
-	if visibility == fs.PRIVATE {
-		mode = l.config.PermModeDirectoryPrivate
+func (l *Local) Visibility(file string) (fs.Visibility, error) {
+	stats, err := os.Stat(l.getPath(file))
+
+	if err != nil {
+		return 0, wrapError("visibility", file, err)
+	}
+
+	return l.visibility(stats), nil
+}
+
+func (l *Local) SetVisibility(file string, visibility fs.Visibility) error {
+	stats, err := os.Stat(l.getPath(file))
+
+	if err == nil {
+		err = os.Chmod(l.getPath(file), l.mode(visibility, stats.IsDir()))
 	}
 
-	return os.MkdirAll(l.getPath(dir), mode)
+	return wrapError("chmod", file, err)
 }
 
 func (l *Local) DeleteDirectory(dir string) error {
-	return os.RemoveAll(l.getPath(dir))
+	return wrapError("rmdir", dir, l.removeAll(l.getPath(dir)))
+}
+
+// MoveDirectory renames the directory if the destination does not exist yet.
+// Otherwise, or across file systems, the files are moved one by one.
+func (l *Local) MoveDirectory(source string, destination string, options fs.DirectoryOptions) error {
+	if _, err := os.Stat(l.getPath(destination)); !os.IsNotExist(err) || strings.Trim(source, "/") == "" {
+		return l.Common.MoveDirectory(source, destination, options)
+	}
+
+	err := l.makeParent(destination, 0)
+
+	if err == nil {
+		err = os.Rename(l.getPath(source), l.getPath(destination))
+	}
+
+	if errors.Is(err, syscall.EXDEV) {
+		return l.Common.MoveDirectory(source, destination, options)
+	}
+
+	return wrapError("move", source, err)
 }
 
-func (l *Local) Files(dir string) []*fs.File {
+func (l *Local) List(dir string) ([]*fs.File, error) {
 	result := make([]*fs.File, 0)
 
-	files := l.getFiles(dir)
+	files, err := l.getFiles(dir)
+
+	if err != nil {
+		return nil, err
+	}
 
 	for _, v := range files {
-		if !v.IsDir() {
-			result = append(result, fs.NewFile(l, l.getPath(dir), v.Name()))
+		if !v.IsDir() && !isMetadata(v.Name()) {
+			result = append(result, l.listedFile(dir, v))
 		}
 	}
 
-	return result
+	return result, nil
 }
 
-func (l *Local) Directories(dir string) []fs.Disk {
+func (l *Local) ListDirectories(dir string) ([]fs.Disk, error) {
 	result := make([]fs.Disk, 0)
 
-	files := l.getFiles(dir)
+	files, err := l.getFiles(dir)
+
+	if err != nil {
+		return nil, err
+	}
 
 	for _, v := range files {
 		if v.IsDir() {
 			//result = append(result, fs.NewDirectory(l, l.getPath(dir), v.Name()))
-			result = append(result, l.Prefix(l.getPath(dir)+"/"+v.Name()))
+			result = append(result, l.Prefix(path.Join(l.getPath(dir), v.Name())))
 		}
 	}
 
-	return result
+	return result, nil
 }
 
-func (l *Local) getFiles(dir string) []os.FileInfo {
-	result := make([]os.FileInfo, 0)
+func (l *Local) getFiles(dir string) ([]os.FileInfo, error) {
+	p := l.getPath(dir)
 
-	f, err := os.Open(l.getPath(dir))
-	defer f.Close()
+	if p == "" {
+		p = "."
+	}
+
+	f, err := os.Open(p)
 
 	if err != nil {
-		return result
+		return nil, wrapError("list", dir, err)
 	}
+	defer f.Close()
 
 	files, err := f.Readdir(0)
+
 	if err != nil {
+		return nil, wrapError("list", dir, err)
+	}
 
-		return result
+	return files, nil
+}
+
+func (l *Local) batches(dir string) (batches, error) {
+	p := l.getPath(dir)
+
+	if p == "" {
+		p = "."
 	}
 
-	return files
+	f, err := os.Open(p)
+
+	if err != nil {
+		return nil, wrapError("list", dir, err)
+	}
+
+	return &localBatches{local: l, dir: dir, file: f}, nil
+}
+
+// localBatches reads a directory in batches, the entries are only sorted within a batch.
+type localBatches struct {
+	local *Local
+	dir   string
+	file  *os.File
 }
 
-func (l *Local) write(file string, content []byte, visibility fs.Visibility) error {
-	mode := l.config.PermModeFilePublic
+func (b *localBatches) Next() ([]fs.Entry, error) {
+	entries, err := b.file.ReadDir(256)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	r := make([]fs.Entry, 0, len(entries))
+
+	for _, entry := range entries {
+		p := path.Join(b.dir, entry.Name())
+
+		if entry.IsDir() {
+			r = append(r, fs.Entry{Path: p, Directory: b.local.Prefix(path.Join(b.local.getPath(b.dir), entry.Name()))})
+		} else if info, err := entry.Info(); err == nil && !isMetadata(entry.Name()) {
+			r = append(r, fs.Entry{Path: p, File: b.local.listedFile(b.dir, info)})
+		}
+	}
+
+	return r, nil
+}
+
+func (b *localBatches) Close() error {
+	return b.file.Close()
+}
+
+// listedFile keeps the attributes of stats. Content type and metadata are only
+// read by Stat.
+func (l *Local) listedFile(dir string, stats os.FileInfo) *fs.File {
+	return fs.NewFileWithAttributes(l, strings.Trim(path.Clean("/"+dir), "/"), stats.Name(), fs.Attributes{
+		Size:         stats.Size(),
+		LastModified: stats.ModTime().Unix(),
+		ETag:         fileETag(stats.Size(), stats.ModTime()),
+		Visibility:   l.visibility(stats),
+	})
+}
+
+func (l *Local) visibility(stats os.FileInfo) fs.Visibility {
+	private := l.config.PermModeFilePrivate
+
+	if stats.IsDir() {
+		private = l.config.PermModeDirectoryPrivate
+	}
+
+	if stats.Mode().Perm() == private {
+		return fs.PRIVATE
+	}
+
+	return fs.PUBLIC
+}
+
+func (l *Local) mode(visibility fs.Visibility, dir bool) os.FileMode {
+	if dir && visibility == fs.PRIVATE {
+		return l.config.PermModeDirectoryPrivate
+	}
+
+	if dir {
+		return l.config.PermModeDirectoryPublic
+	}
+
 	if visibility == fs.PRIVATE {
-		mode = l.config.PermModeFilePrivate
+		return l.config.PermModeFilePrivate
+	}
+
+	return l.config.PermModeFilePublic
+}
+
+func (l *Local) metadataPath(file string) string {
+	dir, name := path.Split(l.getPath(file))
+
+	return dir + "." + name + metadataSuffix
+}
+
+func (l *Local) readMetadata(file string) localMetadata {
+	m := localMetadata{}
+	content, err := os.ReadFile(l.metadataPath(file))
+
+	if err == nil {
+		json.Unmarshal(content, &m)
+	}
+
+	return m
+}
+
+// checksum returns the stored checksum unless the file was changed by others.
+func (m localMetadata) checksum(stats os.FileInfo) string {
+	if m.Size != stats.Size() || m.Modified != stats.ModTime().UnixNano() {
+		return ""
+	}
+
+	return m.Checksum
+}
+
+func (l *Local) writeMetadata(file string, m localMetadata) error {
+	if m.ContentType == "" && m.CacheControl == "" && m.ContentDisposition == "" &&
+		m.ContentEncoding == "" && m.Checksum == "" && len(m.Metadata) == 0 {
+		err := os.Remove(l.metadataPath(file))
+
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
 	}
-	f, err := os.OpenFile(l.getPath(file), os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode)
+
+	content, err := json.Marshal(m)
 
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	_, err = f.Write(content)
+	return os.WriteFile(l.metadataPath(file), content, l.config.PermModeFilePublic)
+}
+
+func (l *Local) removeAll(dir string) error {
+	entries, _ := os.ReadDir(dir)
+
+	for _, entry := range entries {
+		if err := l.Context().Err(); err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if err := l.removeAll(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := l.Context().Err(); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(dir)
+}
+
+func (l *Local) makeParent(file string, visibility fs.Visibility) error {
+	dir, _ := path.Split(file)
+
+	if strings.Trim(dir, "/") == "" {
+		return nil
+	}
+
+	return l.MakeDirectory(dir, visibility)
+}
+
+// write streams content to a temporary file next to file and renames it into
+// place, so a failing reader leaves the existing file untouched.
+func (l *Local) write(file string, content io.Reader, visibility fs.Visibility) error {
+	p := l.getPath(file)
+	mode := l.mode(visibility, false)
+
+	// the mode of existing files is only changed if a visibility is given
+	if stats, err := os.Stat(p); err == nil && visibility == 0 {
+		mode = stats.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".*.tmp")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, content)
+
+	if err == nil {
+		err = f.Chmod(mode)
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), p)
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+	}
 
 	return err
 }
 
-func (l *Local) getPath(path string) string {
+func (l *Local) getPath(file string) string {
 	if l.config.Prefix == "" {
-		return path
+		return file
 	}
 
-	return l.config.Prefix + "/" + strings.Replace(path, l.config.Prefix, "", 1)
+	return path.Join(l.config.Prefix, file)
+}
+
+func isMetadata(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, metadataSuffix)
 }
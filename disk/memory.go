@@ -3,12 +3,22 @@ package disk
 import (
 	"bytes"
 	"context"
-	"errors"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/evolidev/storage/fs"
 	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,27 +28,72 @@ type Memory struct {
 }
 
 func NewMemory(config MemoryConfig) *Memory {
-	return &Memory{S3: NewS3(S3Config{Client: NewMemoryClient(), Prefix: config.Prefix})}
+	return newMemory(config, NewS3(S3Config{Client: NewMemoryClient(), Prefix: config.Prefix, BaseURL: config.BaseURL}))
+}
+
+// newMemory wraps s, the helpers of Common reach the temporary URLs of Memory.
+func newMemory(config MemoryConfig, s *S3) *Memory {
+	m := &Memory{config: config, S3: s}
+	m.disk = m
+
+	return m
 }
 
 func (m *Memory) Prefix(prefix string) fs.Disk {
 	c := m.config
 	c.Prefix = prefix
 
-	return &Memory{config: c, S3: m.S3.Prefix(prefix).(*S3)}
+	return newMemory(c, m.S3.Prefix(prefix).(*S3))
+}
+
+func (m *Memory) WithContext(ctx context.Context) fs.Disk {
+	return newMemory(m.config, m.S3.WithContext(ctx).(*S3))
 }
 
+// TemporaryURL signs the file with the SignKey, serve it with SignedHandler.
+func (m *Memory) TemporaryURL(file string, expires time.Duration) (string, error) {
+	if m.config.BaseURL == "" || len(m.config.SignKey) == 0 {
+		return "", unsupported("url", file)
+	}
+
+	return signedURL(m.config.BaseURL, m.config.SignKey, http.MethodGet, file, expires, url.Values{}), nil
+}
+
+func (m *Memory) TemporaryUploadURL(file string, expires time.Duration, options fs.PutOptions) (string, error) {
+	if m.config.BaseURL == "" || len(m.config.SignKey) == 0 {
+		return "", unsupported("url", file)
+	}
+
+	return signedURL(m.config.BaseURL, m.config.SignKey, http.MethodPut, file, expires, putParams(options, m.config.MaxUploadSize)), nil
+}
+
+// MemoryClient is safe for concurrent use.
 type MemoryClient struct {
-	data map[string]file
+	mu      sync.Mutex
+	data    map[string]file
+	uploads map[string]*upload
+	counter int
 }
 
 func NewMemoryClient() *MemoryClient {
 	return &MemoryClient{
-		data: make(map[string]file),
+		data:    make(map[string]file),
+		uploads: make(map[string]*upload),
 	}
 }
 
 func (m *MemoryClient) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.putObject(ctx, params)
+}
+
+func (m *MemoryClient) putObject(ctx context.Context, params *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	buf := new(bytes.Buffer)
 
 	if params.Body != nil {
@@ -46,16 +101,36 @@ func (m *MemoryClient) PutObject(ctx context.Context, params *s3.PutObjectInput,
 	}
 
 	t := time.Now()
+	sum := md5.Sum(buf.Bytes())
+	storageClass := types.ObjectStorageClassStandard
+
+	if params.StorageClass != "" {
+		storageClass = types.ObjectStorageClass(params.StorageClass)
+	}
 
 	o := types.Object{
 		Key:          params.Key,
 		LastModified: &t,
 		Size:         int64(len(buf.Bytes())),
+		ETag:         aws.String("\"" + hex.EncodeToString(sum[:]) + "\""),
+		StorageClass: storageClass,
 	}
 
 	f := file{
-		object:  o,
-		content: buf.Bytes(),
+		object:               o,
+		content:              buf.Bytes(),
+		contentType:          params.ContentType,
+		cacheControl:         params.CacheControl,
+		contentDisposition:   params.ContentDisposition,
+		contentEncoding:      params.ContentEncoding,
+		serverSideEncryption: params.ServerSideEncryption,
+		acl:                  params.ACL,
+		checksum:             params.ChecksumSHA256,
+		metadata:             make(map[string]string),
+	}
+
+	for k, v := range params.Metadata {
+		f.metadata[k] = v
 	}
 
 	m.data[*params.Key] = f
@@ -64,79 +139,546 @@ func (m *MemoryClient) PutObject(ctx context.Context, params *s3.PutObjectInput,
 }
 
 func (m *MemoryClient) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	if err := m.check(*params.Key); err != nil {
 		return nil, err
 	}
 
+	content := m.data[*params.Key].content
 	o := s3.GetObjectOutput{}
-	o.Body = io.NopCloser(bytes.NewReader(m.data[*params.Key].content))
+
+	if params.Range != nil {
+		start, end, err := parseRange(*params.Range, int64(len(content)))
+
+		if err != nil {
+			return nil, err
+		}
+
+		o.ContentRange = aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, len(content)))
+		content = content[start : end+1]
+	}
+
+	o.ContentLength = int64(len(content))
+	o.Body = io.NopCloser(bytes.NewReader(content))
 
 	return &o, nil
 }
 
 func (m *MemoryClient) GetObjectAttributes(ctx context.Context, params *s3.GetObjectAttributesInput, optFns ...func(*s3.Options)) (*s3.GetObjectAttributesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	if err := m.check(*params.Key); err != nil {
 		return nil, err
 	}
 
+	f := m.data[*params.Key]
 	o := &s3.GetObjectAttributesOutput{}
 
-	o.ObjectSize = m.data[*params.Key].object.Size
-	o.LastModified = m.data[*params.Key].object.LastModified
+	o.ObjectSize = f.object.Size
+	o.LastModified = f.object.LastModified
+	o.ETag = f.object.ETag
+	o.StorageClass = types.StorageClass(f.object.StorageClass)
+
+	if f.checksum != nil {
+		o.Checksum = &types.Checksum{ChecksumSHA256: f.checksum}
+	}
 
 	return o, nil
 }
 
 func (m *MemoryClient) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
-	if err := m.check(*params.Key); err != nil {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 
-	return &s3.HeadObjectOutput{
-		ContentLength: m.data[*params.Key].object.Size,
-		LastModified:  m.data[*params.Key].object.LastModified,
-	}, nil
+	f, ok := m.data[*params.Key]
+
+	if !ok {
+		return nil, &types.NotFound{}
+	}
+
+	o := &s3.HeadObjectOutput{
+		ContentLength:        f.object.Size,
+		LastModified:         f.object.LastModified,
+		ContentType:          f.contentType,
+		CacheControl:         f.cacheControl,
+		ContentDisposition:   f.contentDisposition,
+		ContentEncoding:      f.contentEncoding,
+		ServerSideEncryption: f.serverSideEncryption,
+		ETag:                 f.object.ETag,
+		Metadata:             f.metadata,
+	}
+
+	// like S3 the storage class is only reported if it is not the default one
+	if f.object.StorageClass != types.ObjectStorageClassStandard {
+		o.StorageClass = types.StorageClass(f.object.StorageClass)
+	}
+
+	if params.ChecksumMode == types.ChecksumModeEnabled {
+		o.ChecksumSHA256 = f.checksum
+	}
+
+	return o, nil
 }
 
 func (m *MemoryClient) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
-	ok := false
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	for k, _ := range m.data {
-		if strings.HasPrefix(k, *params.Key) {
-			ok = true
-			break
-		}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
 	delete(m.data, *params.Key)
 
-	return &s3.DeleteObjectOutput{DeleteMarker: ok}, nil
+	return &s3.DeleteObjectOutput{}, nil
 }
 
-func (m *MemoryClient) ListObjects(ctx context.Context, params *s3.ListObjectsInput, optFns ...func(*s3.Options)) (*s3.ListObjectsOutput, error) {
-	r := make([]types.Object, 0)
+// ListObjectsV2 lists the keys in lexicographical order like S3. With a delimiter
+// keys below the next delimiter are rolled up into CommonPrefixes. The continuation
+// token is the encoded last key or common prefix of the previous page.
+func (m *MemoryClient) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	prefix := aws.ToString(params.Prefix)
+	delimiter := aws.ToString(params.Delimiter)
+	after := aws.ToString(params.StartAfter)
+	keys := make([]string, 0)
+	limit := int(params.MaxKeys)
+
+	if params.ContinuationToken != nil {
+		token, err := base64.RawURLEncoding.DecodeString(*params.ContinuationToken)
 
-	for k, v := range m.data {
-		if strings.HasPrefix(k, *params.Prefix) {
-			r = append(r, v.object)
+		if err != nil {
+			return nil, &smithy.GenericAPIError{Code: "InvalidArgument", Message: "The continuation token provided is incorrect"}
+		}
+
+		after = string(token)
+	}
+
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
+	}
+
+	for k := range m.data {
+		if strings.HasPrefix(k, prefix) && k > after {
+			keys = append(keys, k)
 		}
 	}
 
-	o := &s3.ListObjectsOutput{}
-	o.Contents = r
+	sort.Strings(keys)
+
+	o := &s3.ListObjectsV2Output{
+		Prefix:            params.Prefix,
+		Delimiter:         params.Delimiter,
+		MaxKeys:           int32(limit),
+		ContinuationToken: params.ContinuationToken,
+		StartAfter:        params.StartAfter,
+		Contents:          make([]types.Object, 0),
+		CommonPrefixes:    make([]types.CommonPrefix, 0),
+	}
+
+	last := ""
+
+	for _, k := range keys {
+		entry := k
+		i := -1
+
+		if delimiter != "" {
+			i = strings.Index(k[len(prefix):], delimiter)
+		}
+
+		if i >= 0 {
+			entry = k[:len(prefix)+i+len(delimiter)]
+		}
+
+		// skip the keys of a common prefix which was part of the previous page
+		if entry == last || entry == after {
+			continue
+		}
+
+		if int(o.KeyCount) == limit {
+			o.IsTruncated = true
+			o.NextContinuationToken = aws.String(base64.RawURLEncoding.EncodeToString([]byte(last)))
+
+			break
+		}
+
+		if i >= 0 {
+			o.CommonPrefixes = append(o.CommonPrefixes, types.CommonPrefix{Prefix: aws.String(entry)})
+		} else {
+			o.Contents = append(o.Contents, m.data[k].object)
+		}
+
+		last = entry
+		o.KeyCount++
+	}
 
 	return o, nil
 }
 
+func (m *MemoryClient) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.counter++
+	id := strconv.Itoa(m.counter)
+
+	m.uploads[id] = &upload{
+		key:    *params.Key,
+		params: params,
+		parts:  make(map[int32][]byte),
+	}
+
+	return &s3.CreateMultipartUploadOutput{
+		Bucket:   params.Bucket,
+		Key:      params.Key,
+		UploadId: aws.String(id),
+	}, nil
+}
+
+func (m *MemoryClient) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	u, err := m.upload(*params.UploadId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+
+	if params.Body != nil {
+		buf.ReadFrom(params.Body)
+	}
+
+	u.parts[params.PartNumber] = buf.Bytes()
+
+	return &s3.UploadPartOutput{ETag: aws.String(strconv.Itoa(int(params.PartNumber)))}, nil
+}
+
+func (m *MemoryClient) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	u, err := m.upload(*params.UploadId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+
+	for _, part := range params.MultipartUpload.Parts {
+		content, ok := u.parts[part.PartNumber]
+
+		if !ok {
+			return nil, &types.NoSuchUpload{}
+		}
+
+		buf.Write(content)
+	}
+
+	delete(m.uploads, *params.UploadId)
+
+	_, err = m.putObject(ctx, &s3.PutObjectInput{
+		Bucket:               params.Bucket,
+		Key:                  aws.String(u.key),
+		Body:                 buf,
+		ACL:                  u.params.ACL,
+		ContentType:          u.params.ContentType,
+		CacheControl:         u.params.CacheControl,
+		ContentDisposition:   u.params.ContentDisposition,
+		ContentEncoding:      u.params.ContentEncoding,
+		Metadata:             u.params.Metadata,
+		StorageClass:         u.params.StorageClass,
+		ServerSideEncryption: u.params.ServerSideEncryption,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3.CompleteMultipartUploadOutput{Bucket: params.Bucket, Key: aws.String(u.key)}, nil
+}
+
+func (m *MemoryClient) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := m.upload(*params.UploadId); err != nil {
+		return nil, err
+	}
+
+	delete(m.uploads, *params.UploadId)
+
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (m *MemoryClient) GetObjectAcl(ctx context.Context, params *s3.GetObjectAclInput, optFns ...func(*s3.Options)) (*s3.GetObjectAclOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f, ok := m.data[*params.Key]
+
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+
+	owner := &types.Grantee{Type: types.TypeCanonicalUser, ID: aws.String("memory")}
+	grants := []types.Grant{{Grantee: owner, Permission: types.PermissionFullControl}}
+
+	if f.acl == types.ObjectCannedACLPublicRead {
+		grants = append(grants, types.Grant{
+			Grantee:    &types.Grantee{Type: types.TypeGroup, URI: aws.String(allUsers)},
+			Permission: types.PermissionRead,
+		})
+	}
+
+	return &s3.GetObjectAclOutput{Owner: &types.Owner{ID: owner.ID}, Grants: grants}, nil
+}
+
+func (m *MemoryClient) PutObjectAcl(ctx context.Context, params *s3.PutObjectAclInput, optFns ...func(*s3.Options)) (*s3.PutObjectAclOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f, ok := m.data[*params.Key]
+
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+
+	f.acl = params.ACL
+	m.data[*params.Key] = f
+
+	return &s3.PutObjectAclOutput{}, nil
+}
+
+// CopyObject copies the headers and metadata of the source unless the
+// MetadataDirective is REPLACE. Like S3 it does not copy the ACL.
+func (m *MemoryClient) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	source, err := m.source(aws.ToString(params.CopySource))
+
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:               params.Bucket,
+		Key:                  params.Key,
+		Body:                 bytes.NewReader(source.content),
+		ACL:                  params.ACL,
+		ContentType:          source.contentType,
+		CacheControl:         source.cacheControl,
+		ContentDisposition:   source.contentDisposition,
+		ContentEncoding:      source.contentEncoding,
+		Metadata:             source.metadata,
+		StorageClass:         params.StorageClass,
+		ServerSideEncryption: params.ServerSideEncryption,
+		ChecksumSHA256:       source.checksum,
+	}
+
+	if params.MetadataDirective == types.MetadataDirectiveReplace {
+		input.ContentType = params.ContentType
+		input.CacheControl = params.CacheControl
+		input.ContentDisposition = params.ContentDisposition
+		input.ContentEncoding = params.ContentEncoding
+		input.Metadata = params.Metadata
+	}
+
+	if _, err = m.putObject(ctx, input); err != nil {
+		return nil, err
+	}
+
+	o := m.data[*params.Key].object
+
+	return &s3.CopyObjectOutput{CopyObjectResult: &types.CopyObjectResult{ETag: o.ETag, LastModified: o.LastModified}}, nil
+}
+
+func (m *MemoryClient) UploadPartCopy(ctx context.Context, params *s3.UploadPartCopyInput, optFns ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	u, err := m.upload(*params.UploadId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := m.source(aws.ToString(params.CopySource))
+
+	if err != nil {
+		return nil, err
+	}
+
+	content := source.content
+
+	if params.CopySourceRange != nil {
+		start, end, err := parseRange(*params.CopySourceRange, int64(len(content)))
+
+		if err != nil {
+			return nil, err
+		}
+
+		content = content[start : end+1]
+	}
+
+	u.parts[params.PartNumber] = append([]byte(nil), content...)
+	etag := aws.String(strconv.Itoa(int(params.PartNumber)))
+
+	return &s3.UploadPartCopyOutput{CopyPartResult: &types.CopyPartResult{ETag: etag}}, nil
+}
+
+// source finds the object of a copy source of the form bucket/key with an URL encoded key.
+func (m *MemoryClient) source(copySource string) (file, error) {
+	_, key, _ := strings.Cut(copySource, "/")
+	key, err := url.PathUnescape(key)
+
+	if err != nil {
+		return file{}, &smithy.GenericAPIError{Code: "InvalidArgument", Message: "Invalid copy source encoding"}
+	}
+
+	if err := m.check(key); err != nil {
+		return file{}, err
+	}
+
+	return m.data[key], nil
+}
+
+func (m *MemoryClient) upload(id string) (*upload, error) {
+	u, ok := m.uploads[id]
+
+	if !ok {
+		return nil, &types.NoSuchUpload{}
+	}
+
+	return u, nil
+}
+
 func (m *MemoryClient) check(key string) error {
 	if _, ok := m.data[key]; !ok {
-		return errors.New("file does not exists")
+		return &types.NoSuchKey{}
 	}
 
 	return nil
 }
 
+func parseRange(header string, size int64) (int64, int64, error) {
+	invalid := &smithy.GenericAPIError{Code: "InvalidRange", Message: "The requested range is not satisfiable"}
+	spec := strings.TrimPrefix(header, "bytes=")
+	first, last, found := strings.Cut(spec, "-")
+
+	if spec == header || !found || strings.Contains(spec, ",") {
+		return 0, 0, invalid
+	}
+
+	if first == "" {
+		suffix, err := strconv.ParseInt(last, 10, 64)
+
+		if err != nil || suffix <= 0 || size == 0 {
+			return 0, 0, invalid
+		}
+
+		if suffix > size {
+			suffix = size
+		}
+
+		return size - suffix, size - 1, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+
+	if err != nil || start >= size {
+		return 0, 0, invalid
+	}
+
+	end := size - 1
+
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+
+		if err != nil || end < start {
+			return 0, 0, invalid
+		}
+
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return start, end, nil
+}
+
 type file struct {
-	object  types.Object
-	content []byte
+	object               types.Object
+	content              []byte
+	contentType          *string
+	cacheControl         *string
+	contentDisposition   *string
+	contentEncoding      *string
+	serverSideEncryption types.ServerSideEncryption
+	acl                  types.ObjectCannedACL
+	checksum             *string
+	metadata             map[string]string
+}
+
+type upload struct {
+	key    string
+	params *s3.CreateMultipartUploadInput
+	parts  map[int32][]byte
 }
@@ -3,13 +3,22 @@ package disk
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/evolidev/storage/fs"
+	"io"
+	"net/url"
+	"path"
+	"sort"
 	"strings"
+	"time"
 )
 
 type S3 struct {
@@ -27,9 +36,27 @@ type Client interface {
 	GetObjectAttributes(ctx context.Context, params *s3.GetObjectAttributesInput, optFns ...func(*s3.Options)) (*s3.GetObjectAttributesOutput, error)
 	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
 	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
-	ListObjects(ctx context.Context, params *s3.ListObjectsInput, optFns ...func(*s3.Options)) (*s3.ListObjectsOutput, error)
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+	GetObjectAcl(ctx context.Context, params *s3.GetObjectAclInput, optFns ...func(*s3.Options)) (*s3.GetObjectAclOutput, error)
+	PutObjectAcl(ctx context.Context, params *s3.PutObjectAclInput, optFns ...func(*s3.Options)) (*s3.PutObjectAclOutput, error)
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+	UploadPartCopy(ctx context.Context, params *s3.UploadPartCopyInput, optFns ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error)
 }
 
+const defaultPartSize = 5 * 1024 * 1024
+
+// abortTimeout limits aborting a failed multipart upload.
+const abortTimeout = 30 * time.Second
+
+// maxCopySize is the largest object CopyObject accepts and the largest part of UploadPartCopy.
+const maxCopySize = 5 * 1024 * 1024 * 1024
+
+const allUsers = "http://acs.amazonaws.com/groups/global/AllUsers"
+
 func NewS3(config S3Config) *S3 {
 	s := &S3{
 		bucket:    config.Bucket,
@@ -45,76 +72,124 @@ func NewS3(config S3Config) *S3 {
 	return s
 }
 
-func (s *S3) Attributes(file string) fs.Attributes {
-	var size, lastModified int64
-	size = 0
-	lastModified = 0
+func (s *S3) Stat(file string) (fs.Attributes, error) {
+	head, err := s.client.HeadObject(s.Context(), &s3.HeadObjectInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(s.getPath(file)),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
 
-	var attributes []types.ObjectAttributes
-	attributes = append(attributes, types.ObjectAttributesObjectSize)
+	if errorKind(err) == fs.ErrNotFound && s.isDirectory(file) {
+		return fs.Attributes{IsDir: true}, nil
+	}
 
-	key := aws.String(s.getPath(file))
+	if err != nil {
+		return fs.Attributes{}, wrapError("stat", file, err)
+	}
 
-	result, err := s.client.GetObjectAttributes(context.TODO(), &s3.GetObjectAttributesInput{
-		Bucket:           aws.String(s.bucket),
-		Key:              key,
-		ObjectAttributes: attributes,
-	})
+	storageClass := string(head.StorageClass)
+
+	if storageClass == "" {
+		storageClass = string(types.StorageClassStandard)
+	}
+
+	return fs.Attributes{
+		Size:               head.ContentLength,
+		LastModified:       unix(head.LastModified),
+		ContentType:        aws.ToString(head.ContentType),
+		CacheControl:       aws.ToString(head.CacheControl),
+		ContentDisposition: aws.ToString(head.ContentDisposition),
+		ContentEncoding:    aws.ToString(head.ContentEncoding),
+		ETag:               aws.ToString(head.ETag),
+		Checksum:           checksum(head),
+		StorageClass:       storageClass,
+		IsDir:              head.ContentLength == 0,
+		Metadata:           head.Metadata,
+	}, nil
+}
+
+func (s *S3) PutWithOptions(file string, content []byte, options fs.PutOptions) error {
+	return wrapError("put", file, s.putObject(file, content, options))
+}
+
+func (s *S3) PutStream(file string, content io.Reader, options fs.PutOptions) error {
+	part := make([]byte, s.partSize())
+	n, err := io.ReadFull(content, part)
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return s.PutWithOptions(file, part[:n], options)
+	}
 
 	if err == nil {
-		size = result.ObjectSize
-		lastModified = result.LastModified.Unix()
+		err = s.multipartUpload(file, part, content, options)
 	}
 
-	if size == 0 {
-		head, err := s.client.HeadObject(context.TODO(), &s3.HeadObjectInput{
-			Bucket: aws.String(s.bucket),
-			Key:    key,
-		})
+	return wrapError("put", file, err)
+}
 
-		if err == nil {
-			size = head.ContentLength
-		}
+func (s *S3) Get(file string) ([]byte, error) {
+	content, err := s.ReadStream(file)
+
+	if err != nil {
+		return nil, err
 	}
+	defer content.Close()
 
-	return fs.Attributes{
-		Size:         size,
-		LastModified: lastModified,
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(content)
+
+	if err != nil {
+		return nil, wrapError("get", file, err)
 	}
+
+	return buf.Bytes(), nil
 }
 
-func (s *S3) Put(file string, content []byte, visibility fs.Visibility) error {
-	_, err := s.client.PutObject(context.TODO(), &s3.PutObjectInput{
+func (s *S3) ReadStream(file string) (io.ReadCloser, error) {
+	content, err := s.client.GetObject(s.Context(), &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(s.getPath(file)),
-		Body:   bytes.NewReader(content),
 	})
 
-	return err
+	if err != nil {
+		return nil, wrapError("read", file, err)
+	}
+
+	return content.Body, nil
 }
 
-func (s *S3) Get(file string) ([]byte, error) {
-	content, err := s.client.GetObject(context.TODO(), &s3.GetObjectInput{
+func (s *S3) GetRange(file string, offset int64, length int64) ([]byte, error) {
+	if offset < 0 || length < 0 {
+		return nil, wrapError("read", file, errInvalidRange)
+	}
+
+	if length == 0 {
+		return []byte{}, nil
+	}
+
+	content, err := s.client.GetObject(s.Context(), &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(s.getPath(file)),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
 	})
 
-	if err != nil {
-		return nil, err
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidRange" {
+		return []byte{}, nil
 	}
 
-	buf := new(bytes.Buffer)
-	_, err = buf.ReadFrom(content.Body)
-
 	if err != nil {
-		return nil, err
+		return nil, wrapError("read", file, err)
 	}
+	defer content.Body.Close()
 
-	return buf.Bytes(), nil
+	result, err := io.ReadAll(content.Body)
+
+	return result, wrapError("read", file, err)
 }
 
 func (s *S3) Exists(file string) bool {
-	_, err := s.client.HeadObject(context.TODO(), &s3.HeadObjectInput{
+	_, err := s.client.HeadObject(s.Context(), &s3.HeadObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(s.getPath(file)),
 	})
@@ -126,19 +201,87 @@ func (s *S3) Path(file string) string {
 	return s.getPath(file)
 }
 
+func (s *S3) URL(file string) (string, error) {
+	base := s.config.BaseURL
+
+	if base == "" && s.bucket == "" {
+		return "", unsupported("url", file)
+	}
+
+	if base == "" {
+		u, err := s.bucketURL()
+
+		if err != nil {
+			return "", wrapError("url", file, err)
+		}
+
+		base = u
+	}
+
+	return joinURL(base, s.getPath(file)), nil
+}
+
+// TemporaryURL presigns a GetObject request. Other clients than *s3.Client can
+// not presign.
+func (s *S3) TemporaryURL(file string, expires time.Duration) (string, error) {
+	c, ok := s.client.(*s3.Client)
+
+	if !ok {
+		return "", unsupported("url", file)
+	}
+
+	r, err := s3.NewPresignClient(c).PresignGetObject(s.Context(), &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.getPath(file)),
+	}, s3.WithPresignExpires(expires))
+
+	if err != nil {
+		return "", wrapError("url", file, err)
+	}
+
+	return r.URL, nil
+}
+
+// TemporaryUploadURL presigns a PutObject request. Signed headers like x-amz-acl and
+// x-amz-meta-* have to be sent by the client with the upload.
+func (s *S3) TemporaryUploadURL(file string, expires time.Duration, options fs.PutOptions) (string, error) {
+	c, ok := s.client.(*s3.Client)
+
+	if !ok {
+		return "", unsupported("url", file)
+	}
+
+	r, err := s3.NewPresignClient(c).PresignPutObject(s.Context(), &s3.PutObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(s.getPath(file)),
+		ACL:                  acl(options.Visibility),
+		ContentType:          optional(options.ContentType),
+		CacheControl:         optional(options.CacheControl),
+		ContentDisposition:   optional(options.ContentDisposition),
+		ContentEncoding:      optional(options.ContentEncoding),
+		Metadata:             options.Metadata,
+		StorageClass:         types.StorageClass(options.StorageClass),
+		ServerSideEncryption: types.ServerSideEncryption(options.ServerSideEncryption),
+	}, s3.WithPresignExpires(expires))
+
+	if err != nil {
+		return "", wrapError("url", file, err)
+	}
+
+	return r.URL, nil
+}
+
+// Delete removes the files. Like S3 itself it does not report missing files,
+// unversioned buckets never return a delete marker to tell them apart.
 func (s *S3) Delete(files ...string) error {
 	for _, file := range files {
-		tmp, err := s.client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		_, err := s.client.DeleteObject(s.Context(), &s3.DeleteObjectInput{
 			Bucket: aws.String(s.bucket),
 			Key:    aws.String(s.getPath(file)),
 		})
 
-		if err == nil && !tmp.DeleteMarker {
-			err = errors.New("could not delete file")
-		}
-
 		if err != nil {
-			return err
+			return wrapError("delete", file, err)
 		}
 	}
 
@@ -146,108 +289,526 @@ func (s *S3) Delete(files ...string) error {
 }
 
 func (s *S3) MakeDirectory(dir string, visibility fs.Visibility) error {
-	_, err := s.client.PutObject(context.TODO(), &s3.PutObjectInput{
+	_, err := s.client.PutObject(s.Context(), &s3.PutObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(s.getPath(dir)),
+		ACL:    acl(visibility),
 	})
 
-	return err
+	return wrapError("mkdir", dir, err)
 }
 
-func (s *S3) DeleteDirectory(dir string) error {
-	for _, file := range s.AllFiles(s.getPath(dir)) {
-		err := s.Delete(file.Path())
+func (s *S3) Visibility(file string) (fs.Visibility, error) {
+	o, err := s.client.GetObjectAcl(s.Context(), &s3.GetObjectAclInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.getPath(file)),
+	})
 
-		if err != nil {
-			return err
+	if err != nil {
+		return 0, wrapError("visibility", file, err)
+	}
+
+	for _, grant := range o.Grants {
+		if grant.Grantee != nil && aws.ToString(grant.Grantee.URI) == allUsers &&
+			(grant.Permission == types.PermissionRead || grant.Permission == types.PermissionFullControl) {
+			return fs.PUBLIC, nil
 		}
 	}
 
-	for _, d := range s.AllDirectories(s.getPath(dir)) {
-		err := s.Delete(d.Cwd())
+	return fs.PRIVATE, nil
+}
 
-		if err != nil {
+func (s *S3) SetVisibility(file string, visibility fs.Visibility) error {
+	_, err := s.client.PutObjectAcl(s.Context(), &s3.PutObjectAclInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.getPath(file)),
+		ACL:    acl(visibility),
+	})
+
+	return wrapError("chmod", file, err)
+}
+
+func (s *S3) DeleteDirectory(dir string) error {
+	err := s.Walk(dir, func(entry fs.Entry) error {
+		if entry.IsDir() {
+			return nil
+		}
+
+		return s.Delete(entry.Path)
+	})
+
+	if err != nil {
+		return wrapError("rmdir", dir, err)
+	}
+
+	// only directory markers are left
+	dirs := make([]string, 0)
+	err = s.Walk(dir, func(entry fs.Entry) error {
+		dirs = append(dirs, entry.Path)
+
+		return nil
+	})
+
+	if err != nil {
+		return wrapError("rmdir", dir, err)
+	}
+
+	for _, d := range dirs {
+		if err := s.Delete(d); err != nil {
 			return err
 		}
 	}
 
-	s.Delete(s.getPath(dir))
+	s.Delete(dir)
 
 	return nil
 }
 
-func (s *S3) Files(dir string) []*fs.File {
+func (s *S3) List(dir string) ([]*fs.File, error) {
 	r := make([]*fs.File, 0)
 
-	objects, err := s.client.ListObjects(context.TODO(), &s3.ListObjectsInput{
-		Bucket: aws.String(s.bucket),
-		Prefix: aws.String(s.getPath(dir) + s.delimiter),
+	err := s.listObjects(dir, func(objects *s3.ListObjectsV2Output) {
+		page := s.page(dir, objects)
+		r = append(r, page.Files...)
 	})
 
 	if err != nil {
-		return r
+		return nil, wrapError("list", dir, err)
 	}
 
-	for _, object := range objects.Contents {
-		tmp := strings.TrimPrefix(*object.Key, s.getPath(dir)+s.delimiter)
-		if strings.Count(tmp, s.delimiter) > 0 || object.Size == 0 {
-			continue
+	return r, nil
+}
+
+func (s *S3) ListDirectories(dir string) ([]fs.Disk, error) {
+	r := make([]fs.Disk, 0)
+	seen := make(map[string]bool)
+
+	err := s.listObjects(dir, func(objects *s3.ListObjectsV2Output) {
+		for _, d := range s.page(dir, objects).Directories {
+			if !seen[d.Cwd()] {
+				seen[d.Cwd()] = true
+				r = append(r, d)
+			}
 		}
+	})
 
-		f := fs.NewFile(s, s.getPath(dir), tmp)
-		r = append(r, f)
+	if err != nil {
+		return nil, wrapError("list", dir, err)
 	}
 
-	return r
+	return r, nil
 }
 
-func (s *S3) Directories(dir string) []fs.Disk {
-	r := make([]fs.Disk, 0)
-	files := make(map[string]fs.Disk, 0)
+func (s *S3) ListPage(dir string, token string, limit int) (fs.Page, error) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
 
-	objects, err := s.client.ListObjects(context.TODO(), &s3.ListObjectsInput{
-		Bucket: aws.String(s.bucket),
-		Prefix: aws.String(s.getPath(dir) + s.delimiter),
+	objects, err := s.client.ListObjectsV2(s.Context(), &s3.ListObjectsV2Input{
+		Bucket:            aws.String(s.bucket),
+		Prefix:            aws.String(s.getDirectory(dir)),
+		Delimiter:         aws.String(s.delimiter),
+		ContinuationToken: optional(token),
+		MaxKeys:           int32(limit),
 	})
 
 	if err != nil {
-		return r
+		return fs.Page{Files: make([]*fs.File, 0), Directories: make([]fs.Disk, 0)}, wrapError("list", dir, err)
+	}
+
+	page := s.page(dir, objects)
+
+	if objects.IsTruncated {
+		page.Next = aws.ToString(objects.NextContinuationToken)
+	}
+
+	return page, nil
+}
+
+// listObjects calls fn for every page of the objects and common prefixes directly
+// below dir, following all continuation tokens.
+func (s *S3) listObjects(dir string, fn func(objects *s3.ListObjectsV2Output)) error {
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(s.bucket),
+		Prefix:    aws.String(s.getDirectory(dir)),
+		Delimiter: aws.String(s.delimiter),
+	})
+
+	for paginator.HasMorePages() {
+		objects, err := paginator.NextPage(s.Context())
+
+		if err != nil {
+			return err
+		}
+
+		fn(objects)
 	}
 
+	return nil
+}
+
+// page splits a listing into files and directories. Common prefixes and empty
+// objects, as created by MakeDirectory, are directories.
+func (s *S3) page(dir string, objects *s3.ListObjectsV2Output) fs.Page {
+	page := fs.Page{Files: make([]*fs.File, 0), Directories: make([]fs.Disk, 0)}
+	prefix := s.getDirectory(dir)
+	dirs := make(map[string]bool)
+
 	for _, object := range objects.Contents {
-		tmp := strings.TrimPrefix(*object.Key, s.getPath(dir)+s.delimiter)
-		if strings.Count(tmp, s.delimiter) == 0 && object.Size > 0 {
+		name := strings.TrimPrefix(*object.Key, prefix)
+
+		if name == "" || strings.Contains(name, s.delimiter) {
 			continue
 		}
 
-		sp := strings.Split(tmp, s.delimiter)
+		if object.Size > 0 {
+			page.Files = append(page.Files, s.listedFile(strings.Trim(dir, "/"), name, object))
+		} else {
+			dirs[name] = true
+		}
+	}
+
+	for _, p := range objects.CommonPrefixes {
+		if name := strings.TrimSuffix(strings.TrimPrefix(*p.Prefix, prefix), s.delimiter); name != "" {
+			dirs[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(dirs))
+
+	for name := range dirs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
 
-		dirName := s.getPath(dir) + s.delimiter + sp[0]
+	for _, name := range names {
+		page.Directories = append(page.Directories, s.Prefix(prefix+name))
+	}
+
+	return page
+}
 
-		//f := fs.NewDirectory(s, s.getPath(dir), sp[0])
-		f := s.Prefix(s.getPath(dir) + s.delimiter + sp[0])
+// listedFile keeps the attributes a listing returns with every object. Content
+// type and metadata are only known after a Stat.
+func (s *S3) listedFile(dir string, name string, object types.Object) *fs.File {
+	storageClass := string(object.StorageClass)
 
-		files[dirName] = f
+	if storageClass == "" {
+		storageClass = string(types.StorageClassStandard)
 	}
 
-	for _, t := range files {
-		r = append(r, t)
+	return fs.NewFileWithAttributes(s, dir, name, fs.Attributes{
+		Size:         object.Size,
+		LastModified: unix(object.LastModified),
+		ETag:         aws.ToString(object.ETag),
+		StorageClass: storageClass,
+	})
+}
+
+// Glob lists the keys starting with the literal prefix of pattern without a
+// delimiter and matches them on the client.
+func (s *S3) Glob(pattern string) ([]*fs.File, error) {
+	pattern = strings.Trim(pattern, "/")
+	r := make([]*fs.File, 0)
+	root := s.getDirectory("")
+
+	if _, err := fs.Match(pattern, ""); err != nil {
+		return nil, wrapError("glob", pattern, err)
 	}
 
-	return r
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(root + literalPrefix(pattern)),
+	})
+
+	for paginator.HasMorePages() {
+		objects, err := paginator.NextPage(s.Context())
+
+		if err != nil {
+			return nil, wrapError("glob", pattern, err)
+		}
+
+		for _, object := range objects.Contents {
+			name := strings.TrimPrefix(*object.Key, root)
+
+			if ok, _ := fs.Match(pattern, name); ok && object.Size > 0 {
+				dir, file := path.Split(name)
+				r = append(r, s.listedFile(strings.TrimSuffix(dir, "/"), file, object))
+			}
+		}
+	}
+
+	sortFiles(r)
+
+	return r, nil
+}
+
+func (s *S3) isDirectory(dir string) bool {
+	objects, err := s.client.ListObjectsV2(s.Context(), &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(s.getDirectory(dir)),
+		MaxKeys: 1,
+	})
+
+	return err == nil && len(objects.Contents) > 0
 }
 
 func (s *S3) Prefix(prefix string) fs.Disk {
 	c := s.config
 	c.Prefix = prefix
+	d := NewS3(c)
+	d.ctx = s.ctx
 
-	return NewS3(c)
+	return d
 }
 
 func (s *S3) Cwd() string {
 	return s.config.Prefix
 }
 
+func (s *S3) WithContext(ctx context.Context) fs.Disk {
+	c := *s
+	c.Common = &Common{disk: &c, ctx: ctx}
+
+	return &c
+}
+
+func (s *S3) putObject(file string, content []byte, options fs.PutOptions) error {
+	_, err := s.client.PutObject(s.Context(), &s3.PutObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(s.getPath(file)),
+		Body:                 bytes.NewReader(content),
+		ACL:                  acl(options.Visibility),
+		ContentType:          optional(options.ContentType),
+		CacheControl:         optional(options.CacheControl),
+		ContentDisposition:   optional(options.ContentDisposition),
+		ContentEncoding:      optional(options.ContentEncoding),
+		Metadata:             options.Metadata,
+		StorageClass:         types.StorageClass(options.StorageClass),
+		ServerSideEncryption: types.ServerSideEncryption(options.ServerSideEncryption),
+	})
+
+	return err
+}
+
+func (s *S3) multipartUpload(file string, part []byte, content io.Reader, options fs.PutOptions) error {
+	key := aws.String(s.getPath(file))
+
+	upload, err := s.client.CreateMultipartUpload(s.Context(), &s3.CreateMultipartUploadInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  key,
+		ACL:                  acl(options.Visibility),
+		ContentType:          optional(options.ContentType),
+		CacheControl:         optional(options.CacheControl),
+		ContentDisposition:   optional(options.ContentDisposition),
+		ContentEncoding:      optional(options.ContentEncoding),
+		Metadata:             options.Metadata,
+		StorageClass:         types.StorageClass(options.StorageClass),
+		ServerSideEncryption: types.ServerSideEncryption(options.ServerSideEncryption),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	parts, err := s.uploadParts(key, upload.UploadId, part, content)
+
+	return s.completeUpload(key, upload.UploadId, parts, err)
+}
+
+// completeUpload completes a multipart upload, or aborts it if uploading the parts failed.
+func (s *S3) completeUpload(key *string, uploadId *string, parts []types.CompletedPart, err error) error {
+	if err == nil {
+		_, err = s.client.CompleteMultipartUpload(s.Context(), &s3.CompleteMultipartUploadInput{
+			Bucket:          aws.String(s.bucket),
+			Key:             key,
+			UploadId:        uploadId,
+			MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+		})
+	}
+
+	if err != nil {
+		// the upload has to be aborted even if the caller's context is done
+		ctx, cancel := context.WithTimeout(context.Background(), abortTimeout)
+		defer cancel()
+
+		s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucket),
+			Key:      key,
+			UploadId: uploadId,
+		})
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *S3) uploadParts(key *string, uploadId *string, part []byte, content io.Reader) ([]types.CompletedPart, error) {
+	parts := make([]types.CompletedPart, 0)
+	n := len(part)
+
+	for number := int32(1); n > 0; number++ {
+		result, err := s.client.UploadPart(s.Context(), &s3.UploadPartInput{
+			Bucket:        aws.String(s.bucket),
+			Key:           key,
+			UploadId:      uploadId,
+			PartNumber:    number,
+			ContentLength: int64(n),
+			Body:          bytes.NewReader(part[:n]),
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, types.CompletedPart{ETag: result.ETag, PartNumber: number})
+
+		n, err = io.ReadFull(content, part)
+
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+	}
+
+	return parts, nil
+}
+
+// copy copies the object on the server. CopyObject keeps the headers and metadata,
+// the ACL and storage class have to be passed again.
+func (s *S3) copy(source string, destination string) error {
+	head, err := s.client.HeadObject(s.Context(), &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.getPath(source)),
+	})
+
+	if err != nil {
+		return wrapError("copy", source, err)
+	}
+
+	visibility, err := s.Visibility(source)
+
+	if err != nil {
+		visibility = 0
+	}
+
+	if head.ContentLength > s.copyPartSize() {
+		return wrapError("copy", destination, s.multipartCopy(source, destination, head, visibility))
+	}
+
+	_, err = s.client.CopyObject(s.Context(), &s3.CopyObjectInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  aws.String(s.getPath(destination)),
+		CopySource:           aws.String(joinURL(s.bucket, s.getPath(source))),
+		MetadataDirective:    types.MetadataDirectiveCopy,
+		ACL:                  acl(visibility),
+		StorageClass:         head.StorageClass,
+		ServerSideEncryption: head.ServerSideEncryption,
+	})
+
+	return wrapError("copy", destination, err)
+}
+
+func (s *S3) move(source string, destination string) error {
+	if err := s.copy(source, destination); err != nil {
+		return err
+	}
+
+	return s.Delete(source)
+}
+
+// multipartCopy copies objects too large for CopyObject with UploadPartCopy.
+func (s *S3) multipartCopy(source string, destination string, head *s3.HeadObjectOutput, visibility fs.Visibility) error {
+	key := aws.String(s.getPath(destination))
+
+	upload, err := s.client.CreateMultipartUpload(s.Context(), &s3.CreateMultipartUploadInput{
+		Bucket:               aws.String(s.bucket),
+		Key:                  key,
+		ACL:                  acl(visibility),
+		ContentType:          head.ContentType,
+		CacheControl:         head.CacheControl,
+		ContentDisposition:   head.ContentDisposition,
+		ContentEncoding:      head.ContentEncoding,
+		Metadata:             head.Metadata,
+		StorageClass:         head.StorageClass,
+		ServerSideEncryption: head.ServerSideEncryption,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	parts := make([]types.CompletedPart, 0)
+	size := s.copyPartSize()
+
+	for number, offset := int32(1), int64(0); offset < head.ContentLength && err == nil; number, offset = number+1, offset+size {
+		end := offset + size - 1
+
+		if end >= head.ContentLength {
+			end = head.ContentLength - 1
+		}
+
+		var result *s3.UploadPartCopyOutput
+		result, err = s.client.UploadPartCopy(s.Context(), &s3.UploadPartCopyInput{
+			Bucket:          aws.String(s.bucket),
+			Key:             key,
+			UploadId:        upload.UploadId,
+			PartNumber:      number,
+			CopySource:      aws.String(joinURL(s.bucket, s.getPath(source))),
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", offset, end)),
+		})
+
+		if err == nil {
+			parts = append(parts, types.CompletedPart{ETag: result.CopyPartResult.ETag, PartNumber: number})
+		}
+	}
+
+	return s.completeUpload(key, upload.UploadId, parts, err)
+}
+
+func (s *S3) copyPartSize() int64 {
+	if s.config.CopyPartSize > 0 {
+		return s.config.CopyPartSize
+	}
+
+	return maxCopySize
+}
+
+func (s *S3) partSize() int64 {
+	if s.config.PartSize > 0 {
+		return s.config.PartSize
+	}
+
+	return defaultPartSize
+}
+
+// bucketURL derives the URL of the bucket from the endpoint. Without an endpoint
+// the AWS virtual-hosted style URL is used.
+func (s *S3) bucketURL() (string, error) {
+	endpoint := s.config.Endpoint
+
+	if endpoint == "" && s.config.Region != "" {
+		endpoint = "https://s3." + s.config.Region + ".amazonaws.com"
+	} else if endpoint == "" {
+		endpoint = "https://s3.amazonaws.com"
+	}
+
+	u, err := url.Parse(endpoint)
+
+	if err != nil {
+		return "", err
+	}
+
+	if s.config.UsePathStyle {
+		u.Path = path.Join("/", u.Path, s.bucket)
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+
+	return u.String(), nil
+}
+
 func (s *S3) Options() *s3.Options {
 	return s.options
 }
@@ -257,7 +818,7 @@ func (s *S3) getClient(config S3Config) Client {
 		return config.Client
 	}
 
-	options := s3.Options{}
+	options := s3.Options{Region: config.Region, UsePathStyle: config.UsePathStyle}
 	buildCredentials(&config, &options)
 	buildEndpoint(&config, &options)
 
@@ -293,10 +854,76 @@ func buildEndpoint(config *S3Config, options *s3.Options) {
 	}
 }
 
+func checksum(head *s3.HeadObjectOutput) string {
+	checksums := []struct {
+		algorithm string
+		value     *string
+	}{
+		{"sha256", head.ChecksumSHA256},
+		{"sha1", head.ChecksumSHA1},
+		{"crc32c", head.ChecksumCRC32C},
+		{"crc32", head.ChecksumCRC32},
+	}
+
+	for _, c := range checksums {
+		if c.value == nil {
+			continue
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(*c.value)
+
+		if err == nil {
+			return c.algorithm + ":" + hex.EncodeToString(raw)
+		}
+	}
+
+	return ""
+}
+
+func unix(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+
+	return t.Unix()
+}
+
+// acl maps a visibility to a canned ACL. Without a visibility the bucket default is used.
+func acl(visibility fs.Visibility) types.ObjectCannedACL {
+	switch visibility {
+	case fs.PUBLIC:
+		return types.ObjectCannedACLPublicRead
+	case fs.PRIVATE:
+		return types.ObjectCannedACLPrivate
+	}
+
+	return ""
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+
+	return aws.String(value)
+}
+
 func (s *S3) getPath(path string) string {
 	if s.config.Prefix == "" {
 		return path
 	}
 
+	if path == "" {
+		return s.config.Prefix
+	}
+
 	return s.config.Prefix + "/" + path
 }
+
+func (s *S3) getDirectory(dir string) string {
+	if s.getPath(dir) == "" {
+		return ""
+	}
+
+	return s.getPath(dir) + s.delimiter
+}
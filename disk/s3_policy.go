@@ -0,0 +1,149 @@
+package disk
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/evolidev/storage/fs"
+	"strings"
+	"time"
+)
+
+// PostPolicyOptions restricts the uploads accepted by a POST policy.
+// A ContentType ending with "/", like "image/", accepts every type starting with it,
+// the browser then has to send the Content-Type form field itself.
+type PostPolicyOptions struct {
+	fs.PutOptions
+	MinSize int64
+	MaxSize int64
+}
+
+// PostPolicy holds the form fields for a direct browser upload. Post them as
+// multipart/form-data to URL together with the "file" field last.
+type PostPolicy struct {
+	URL    string
+	Fields map[string]string
+}
+
+func (s *S3) PostPolicy(file string, expires time.Duration, options PostPolicyOptions) (PostPolicy, error) {
+	credentials := s.credentials()
+
+	if credentials == nil || s.bucket == "" {
+		return PostPolicy{}, unsupported("url", file)
+	}
+
+	creds, err := credentials.Retrieve(s.Context())
+
+	if err != nil {
+		return PostPolicy{}, wrapError("url", file, err)
+	}
+
+	u, err := s.bucketURL()
+
+	if err != nil {
+		return PostPolicy{}, wrapError("url", file, err)
+	}
+
+	region := s.config.Region
+
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	now := time.Now().UTC()
+	date := now.Format("20060102")
+	fields := policyFields(s.getPath(file), options)
+	fields["x-amz-algorithm"] = "AWS4-HMAC-SHA256"
+	fields["x-amz-credential"] = creds.AccessKeyID + "/" + date + "/" + region + "/s3/aws4_request"
+	fields["x-amz-date"] = now.Format("20060102T150405Z")
+
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken
+	}
+
+	conditions := []interface{}{map[string]string{"bucket": s.bucket}}
+
+	for k, v := range fields {
+		conditions = append(conditions, map[string]string{k: v})
+	}
+
+	if strings.HasSuffix(options.ContentType, "/") {
+		conditions = append(conditions, []interface{}{"starts-with", "$Content-Type", options.ContentType})
+	}
+
+	if options.MaxSize > 0 {
+		conditions = append(conditions, []interface{}{"content-length-range", options.MinSize, options.MaxSize})
+	}
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"expiration": now.Add(expires).Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+
+	if err != nil {
+		return PostPolicy{}, wrapError("url", file, err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(policy)
+	key := []byte("AWS4" + creds.SecretAccessKey)
+
+	for _, part := range []string{date, region, "s3", "aws4_request", encoded} {
+		key = hmacSHA256(key, part)
+	}
+
+	fields["policy"] = encoded
+	fields["x-amz-signature"] = hex.EncodeToString(key)
+
+	return PostPolicy{URL: u, Fields: fields}, nil
+}
+
+func (s *S3) credentials() aws.CredentialsProvider {
+	options := s.options
+
+	if options == nil || options.Credentials == nil {
+		config := s.config
+		options = &s3.Options{}
+		buildCredentials(&config, options)
+	}
+
+	return options.Credentials
+}
+
+func policyFields(key string, options PostPolicyOptions) map[string]string {
+	fields := map[string]string{"key": key}
+	headers := map[string]string{
+		"acl":                          string(acl(options.Visibility)),
+		"Cache-Control":                options.CacheControl,
+		"Content-Disposition":          options.ContentDisposition,
+		"Content-Encoding":             options.ContentEncoding,
+		"x-amz-storage-class":          options.StorageClass,
+		"x-amz-server-side-encryption": options.ServerSideEncryption,
+	}
+
+	if !strings.HasSuffix(options.ContentType, "/") {
+		headers["Content-Type"] = options.ContentType
+	}
+
+	for k, v := range options.Metadata {
+		headers["x-amz-meta-"+k] = v
+	}
+
+	for k, v := range headers {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+
+	return fields
+}
+
+func hmacSHA256(key []byte, content string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(content))
+
+	return mac.Sum(nil)
+}
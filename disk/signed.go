@@ -0,0 +1,255 @@
+package disk
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/evolidev/storage/fs"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignedHandler serves the files of a disk behind the temporary URLs created by
+// Local and Memory and accepts uploads to their temporary upload URLs.
+// Mount it at the BaseURL of the disk, e.g. with http.StripPrefix. Without a key
+// every request is rejected.
+type SignedHandler struct {
+	disk fs.Disk
+	key  []byte
+}
+
+func NewSignedHandler(disk fs.Disk, key []byte) *SignedHandler {
+	return &SignedHandler{disk: disk, key: key}
+}
+
+func (h *SignedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	file := strings.TrimPrefix(r.URL.Path, "/")
+	method := r.Method
+
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+
+	if method != http.MethodGet && method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if !verify(h.key, method, file, r.URL.Query()) {
+		http.Error(w, "invalid or expired signature", http.StatusForbidden)
+
+		return
+	}
+
+	if method == http.MethodPut {
+		h.upload(w, r, file)
+
+		return
+	}
+
+	h.serve(w, r, file)
+}
+
+// upload stores the body unless it exceeds the signed max_size.
+func (h *SignedHandler) upload(w http.ResponseWriter, r *http.Request, file string) {
+	limit, err := strconv.ParseInt(r.URL.Query().Get("max_size"), 10, 64)
+
+	if err != nil {
+		http.Error(w, "missing upload size limit", http.StatusForbidden)
+
+		return
+	}
+
+	if r.ContentLength > limit {
+		http.Error(w, "request entity too large", http.StatusRequestEntityTooLarge)
+
+		return
+	}
+
+	body := &countingReader{ReadCloser: r.Body}
+	err = h.disk.WithContext(r.Context()).PutStream(file, http.MaxBytesReader(w, body, limit), putOptions(r.URL.Query()))
+
+	// MaxBytesReader reads one byte past the limit to notice a larger body
+	if err != nil && body.count > limit {
+		http.Error(w, "request entity too large", http.StatusRequestEntityTooLarge)
+
+		return
+	}
+
+	if err != nil {
+		httpError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *SignedHandler) serve(w http.ResponseWriter, r *http.Request, file string) {
+	disk := h.disk.WithContext(r.Context())
+	a, err := disk.Stat(file)
+
+	if err == nil && a.IsDir {
+		err = fs.ErrNotFound
+	}
+
+	if err != nil {
+		httpError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Last-Modified", time.Unix(a.LastModified, 0).UTC().Format(http.TimeFormat))
+
+	if a.ContentType != "" {
+		w.Header().Set("Content-Type", a.ContentType)
+	}
+
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	content, err := disk.ReadStream(file)
+
+	if err != nil {
+		httpError(w, err)
+
+		return
+	}
+	defer content.Close()
+
+	io.Copy(w, content)
+}
+
+// defaultMaxUploadSize is the largest object a single S3 PutObject accepts.
+const defaultMaxUploadSize = 5 * 1024 * 1024 * 1024
+
+func httpError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotFound):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, "forbidden", http.StatusForbidden)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// signedURL appends the expiry and a signature over method, file and query to base.
+func signedURL(base string, key []byte, method string, file string, expires time.Duration, query url.Values) string {
+	file = strings.Trim(path.Clean("/"+file), "/")
+	query.Set("expires", strconv.FormatInt(time.Now().Add(expires).Unix(), 10))
+	query.Set("signature", sign(key, method, file, query))
+
+	return joinURL(base, file) + "?" + query.Encode()
+}
+
+func verify(key []byte, method string, file string, query url.Values) bool {
+	expiry, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+
+	if len(key) == 0 || err != nil || time.Now().Unix() > expiry {
+		return false
+	}
+
+	expected := sign(key, method, file, query)
+
+	return hmac.Equal([]byte(expected), []byte(query.Get("signature")))
+}
+
+func sign(key []byte, method string, file string, query url.Values) string {
+	signed := url.Values{}
+
+	for k, v := range query {
+		if k != "signature" {
+			signed[k] = v
+		}
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(method + "\n" + file + "\n" + signed.Encode()))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// putParams encodes the options and the size limit of a signed upload, they are
+// covered by the signature.
+func putParams(options fs.PutOptions, maxSize int64) url.Values {
+	if maxSize <= 0 {
+		maxSize = defaultMaxUploadSize
+	}
+
+	query := url.Values{"max_size": {strconv.FormatInt(maxSize, 10)}}
+	params := map[string]string{
+		"content_type":        options.ContentType,
+		"cache_control":       options.CacheControl,
+		"content_disposition": options.ContentDisposition,
+		"content_encoding":    options.ContentEncoding,
+	}
+
+	switch options.Visibility {
+	case fs.PUBLIC:
+		params["visibility"] = "public"
+	case fs.PRIVATE:
+		params["visibility"] = "private"
+	}
+
+	for k, v := range options.Metadata {
+		params["meta-"+k] = v
+	}
+
+	for k, v := range params {
+		if v != "" {
+			query.Set(k, v)
+		}
+	}
+
+	return query
+}
+
+type countingReader struct {
+	io.ReadCloser
+	count int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.count += int64(n)
+
+	return n, err
+}
+
+func putOptions(query url.Values) fs.PutOptions {
+	options := fs.PutOptions{
+		ContentType:        query.Get("content_type"),
+		CacheControl:       query.Get("cache_control"),
+		ContentDisposition: query.Get("content_disposition"),
+		ContentEncoding:    query.Get("content_encoding"),
+	}
+
+	switch query.Get("visibility") {
+	case "public":
+		options.Visibility = fs.PUBLIC
+	case "private":
+		options.Visibility = fs.PRIVATE
+	}
+
+	for k := range query {
+		if strings.HasPrefix(k, "meta-") {
+			if options.Metadata == nil {
+				options.Metadata = make(map[string]string)
+			}
+
+			options.Metadata[strings.TrimPrefix(k, "meta-")] = query.Get(k)
+		}
+	}
+
+	return options
+}
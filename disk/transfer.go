@@ -0,0 +1,61 @@
+package disk
+
+import (
+	"github.com/evolidev/storage/fs"
+	"path"
+)
+
+// Transfer streams a file from one disk to another, keeping its visibility,
+// headers and metadata as far as the destination supports them.
+func Transfer(source fs.Disk, sourcePath string, destination fs.Disk, destinationPath string, options fs.TransferOptions) error {
+	// rewriting a file with itself would truncate it first
+	same := source == destination && samePath(sourcePath, destinationPath)
+
+	if same && options.Visibility != 0 {
+		return source.SetVisibility(sourcePath, options.Visibility)
+	}
+
+	a, err := source.Stat(sourcePath)
+
+	if err != nil || same {
+		return err
+	}
+
+	visibility := options.Visibility
+
+	if visibility == 0 {
+		visibility = a.Visibility
+	}
+
+	if visibility == 0 {
+		visibility, _ = source.Visibility(sourcePath)
+	}
+
+	content, err := source.ReadStream(sourcePath)
+
+	if err != nil {
+		return err
+	}
+
+	err = destination.PutStream(destinationPath, content, fs.PutOptions{
+		Visibility:         visibility,
+		ContentType:        a.ContentType,
+		CacheControl:       a.CacheControl,
+		ContentDisposition: a.ContentDisposition,
+		ContentEncoding:    a.ContentEncoding,
+		Metadata:           a.Metadata,
+		StorageClass:       a.StorageClass,
+	})
+	content.Close()
+
+	if err != nil || !options.Move {
+		return err
+	}
+
+	return source.Delete(sourcePath)
+}
+
+// samePath tells whether two paths of one disk name the same file.
+func samePath(a string, b string) bool {
+	return path.Clean("/"+a) == path.Clean("/"+b)
+}
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"github.com/evolidev/storage/disk"
 	"github.com/evolidev/storage/fs"
 	"testing"
@@ -53,3 +55,22 @@ func TestFileDelete(t *testing.T) {
 		t.Errorf("failed to delete file")
 	}
 }
+
+func TestFileWithContext(t *testing.T) {
+	t.Parallel()
+	storage := disk.NewMemory(disk.MemoryConfig{})
+	file := fs.NewFile(storage, "context", "test.txt")
+	file.Put([]byte("test"), fs.PUBLIC)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := file.WithContext(ctx).Get()
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context error but got %s", err)
+	}
+
+	if _, err := file.Get(); err != nil {
+		t.Errorf("original file got adjusted")
+	}
+}
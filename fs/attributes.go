@@ -1,6 +1,22 @@
 package fs
 
 type Attributes struct {
-	Size         int64
-	LastModified int64
+	Size               int64
+	LastModified       int64
+	ContentType        string
+	CacheControl       string
+	ContentDisposition string
+	ContentEncoding    string
+	ETag               string
+	// Checksum is prefixed with the algorithm and hex encoded, e.g. "sha256:9f86d0...".
+	// It is empty if the disk does not know a checksum for the file. S3 only has one
+	// if it was sent on upload, the local disk keeps the one computed on write until
+	// the file is changed by others.
+	Checksum string
+	// Visibility is unset on S3, which would need another request for the ACL.
+	// Disk.Visibility fetches it.
+	Visibility   Visibility
+	StorageClass string
+	IsDir        bool
+	Metadata     map[string]string
 }
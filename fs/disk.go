@@ -1,5 +1,11 @@
 package fs
 
+import (
+	"context"
+	"io"
+	"time"
+)
+
 //'file' => [
 //'public' => 0644,
 //'private' => 0600,
@@ -13,17 +19,26 @@ type Visibility uint32
 
 const (
 	PUBLIC  Visibility = 0755
-	PRIVATE            = 0600
+	PRIVATE Visibility = 0600
 )
 
 type BaseOperation interface {
 	Put(file string, content []byte, visibility Visibility) error
+	PutWithOptions(file string, content []byte, options PutOptions) error
+	PutStream(file string, content io.Reader, options PutOptions) error
 	Get(file string) ([]byte, error)
+	ReadStream(file string) (io.ReadCloser, error)
+	GetRange(file string, offset int64, length int64) ([]byte, error)
 	Attributes(file string) Attributes
+	Stat(file string) (Attributes, error)
 	Exists(file string) bool
 	Delete(files ...string) error
 	Directories(dir string) []Disk
+	ListDirectories(dir string) ([]Disk, error)
 	Files(dir string) []*File
+	List(dir string) ([]*File, error)
+	// ListPage lists at most limit entries of dir following the entries of token.
+	ListPage(dir string, token string, limit int) (Page, error)
 }
 
 type Disk interface {
@@ -32,15 +47,28 @@ type Disk interface {
 	Size(file string) int64
 	LastModified(file string) int64
 	Path(file string) string
+	URL(file string) (string, error)
+	TemporaryURL(file string, expires time.Duration) (string, error)
+	TemporaryUploadURL(file string, expires time.Duration, options PutOptions) (string, error)
 	Prepend(file string, content []byte) error
 	Append(file string, content []byte) error
 	Copy(source string, destination string) error
 	Move(source string, destination string) error
 	MakeDirectory(dir string, visibility Visibility) error
+	CopyDirectory(source string, destination string, options DirectoryOptions) error
+	MoveDirectory(source string, destination string, options DirectoryOptions) error
+	Visibility(file string) (Visibility, error)
+	SetVisibility(file string, visibility Visibility) error
 	DeleteDirectory(dir string) error
 	AllFiles(dir string) []*File
 	AllDirectories(dir string) []Disk
+	Walk(dir string, fn WalkFunc) error
+	Glob(pattern string) ([]*File, error)
+	Query(dir string) *Query
+	Iter(dir string) Iterator
 	File(file string) *File
 	Prefix(prefix string) Disk
 	Cwd() string
+	WithContext(ctx context.Context) Disk
+	Context() context.Context
 }
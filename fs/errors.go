@@ -0,0 +1,73 @@
+package fs
+
+import (
+	"errors"
+	iofs "io/fs"
+	"strings"
+)
+
+// The sentinel errors share their identity with the io/fs errors, so
+// errors.Is(err, os.ErrNotExist) keeps working for every disk.
+var (
+	ErrNotFound    = iofs.ErrNotExist
+	ErrExists      = iofs.ErrExist
+	ErrPermission  = iofs.ErrPermission
+	ErrReadOnly    = errors.New("disk is read-only")
+	ErrUnsupported = errors.New("operation not supported by disk")
+)
+
+// Error records an error together with the operation and the file that caused it.
+// Kind holds one of the sentinel errors above, Err the original error of the backend.
+type Error struct {
+	Op   string
+	Path string
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Op + " " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// Errors collects the errors of an operation on many files.
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Is reports whether any of the errors matches target.
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first of the errors that matches target.
+func (e Errors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
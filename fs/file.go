@@ -1,9 +1,16 @@
 package fs
 
+import (
+	"context"
+	"io"
+	"time"
+)
+
 type File struct {
-	storage Disk
-	path    string
-	name    string
+	storage    Disk
+	path       string
+	name       string
+	attributes *Attributes
 }
 
 func (f *File) Write(p []byte) (n int, err error) {
@@ -18,23 +25,106 @@ func NewFile(store Disk, path string, name string) *File {
 	}
 }
 
+// NewFileWithAttributes creates a file with known attributes, e.g. from a listing,
+// so Stat, Size and LastModified do not have to ask the disk again.
+func NewFileWithAttributes(store Disk, path string, name string, attributes Attributes) *File {
+	f := NewFile(store, path, name)
+	f.attributes = &attributes
+
+	return f
+}
+
 func (f *File) Put(content []byte, visibility Visibility) error {
+	f.attributes = nil
+
 	return f.storage.Put(f.fullName(), content, visibility)
 }
 
+func (f *File) PutWithOptions(content []byte, options PutOptions) error {
+	f.attributes = nil
+
+	return f.storage.PutWithOptions(f.fullName(), content, options)
+}
+
+func (f *File) PutStream(content io.Reader, options PutOptions) error {
+	f.attributes = nil
+
+	return f.storage.PutStream(f.fullName(), content, options)
+}
+
 func (f *File) Get() ([]byte, error) {
 	return f.storage.Get(f.fullName())
 }
 
+func (f *File) ReadStream() (io.ReadCloser, error) {
+	return f.storage.ReadStream(f.fullName())
+}
+
+func (f *File) ReadAt(p []byte, off int64) (n int, err error) {
+	content, err := f.storage.GetRange(f.fullName(), off, int64(len(p)))
+	n = copy(p, content)
+
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+func (f *File) Visibility() (Visibility, error) {
+	return f.storage.Visibility(f.fullName())
+}
+
+func (f *File) SetVisibility(visibility Visibility) error {
+	f.attributes = nil
+
+	return f.storage.SetVisibility(f.fullName(), visibility)
+}
+
 func (f *File) Delete() error {
+	f.attributes = nil
+
 	return f.storage.Delete(f.fullName())
 }
 
+// Stat returns the attributes of the file. They are fetched once and kept
+// until the file is changed through its methods. Files from a listing carry
+// the attributes of the listing, which lack the content type and metadata,
+// use Refresh to fetch all of them.
+func (f *File) Stat() (Attributes, error) {
+	if f.attributes != nil {
+		return *f.attributes, nil
+	}
+
+	return f.Refresh()
+}
+
+// Refresh fetches the attributes from the disk again.
+func (f *File) Refresh() (Attributes, error) {
+	a, err := f.storage.Stat(f.fullName())
+
+	if err != nil {
+		return a, err
+	}
+
+	f.attributes = &a
+
+	return a, nil
+}
+
 func (f *File) Size() int64 {
+	if f.attributes != nil {
+		return f.attributes.Size
+	}
+
 	return f.storage.Size(f.fullName())
 }
 
 func (f *File) LastModified() int64 {
+	if f.attributes != nil {
+		return f.attributes.LastModified
+	}
+
 	return f.storage.LastModified(f.fullName())
 }
 
@@ -42,11 +132,27 @@ func (f *File) Path() string {
 	return f.storage.Path(f.fullName())
 }
 
+func (f *File) URL() (string, error) {
+	return f.storage.URL(f.fullName())
+}
+
+func (f *File) TemporaryURL(expires time.Duration) (string, error) {
+	return f.storage.TemporaryURL(f.fullName(), expires)
+}
+
+func (f *File) TemporaryUploadURL(expires time.Duration, options PutOptions) (string, error) {
+	return f.storage.TemporaryUploadURL(f.fullName(), expires, options)
+}
+
 func (f *File) Prepend(content []byte) error {
+	f.attributes = nil
+
 	return f.storage.Prepend(f.fullName(), content)
 }
 
 func (f *File) Append(content []byte) error {
+	f.attributes = nil
+
 	return f.storage.Append(f.fullName(), content)
 }
 
@@ -55,9 +161,22 @@ func (f *File) Copy(target string) error {
 }
 
 func (f *File) Move(target string) error {
+	f.attributes = nil
+
 	return f.storage.Move(f.fullName(), target)
 }
 
+func (f *File) WithContext(ctx context.Context) *File {
+	c := NewFile(f.storage.WithContext(ctx), f.path, f.name)
+	c.attributes = f.attributes
+
+	return c
+}
+
+func (f *File) Context() context.Context {
+	return f.storage.Context()
+}
+
 func (f *File) Name() string {
 	return f.name
 }
@@ -0,0 +1,50 @@
+package fs
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether name matches the shell pattern. Besides the syntax of
+// path.Match a "**" segment matches zero or more directories.
+func Match(pattern string, name string) (bool, error) {
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+
+	for _, segment := range segments {
+		if segment == "**" {
+			continue
+		}
+
+		if _, err := path.Match(segment, ""); err != nil {
+			return false, err
+		}
+	}
+
+	return match(segments, strings.Split(strings.Trim(name, "/"), "/")), nil
+}
+
+func match(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if match(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
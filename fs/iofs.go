@@ -0,0 +1,363 @@
+package fs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	iofs "io/fs"
+	"path"
+	"sort"
+	"time"
+)
+
+// chunkSize is the size of the ranges read from the disk once a file was
+// seeked away from its beginning.
+const chunkSize = 4 * 1024 * 1024
+
+// IOFS exposes a Disk as a standard library io/fs.FS so it can be used with
+// http.FS, template.ParseFS, fs.WalkDir and friends. Files are io.Seekers, so
+// http.FileServer can serve ranges.
+type IOFS struct {
+	disk Disk
+}
+
+func NewIOFS(disk Disk) *IOFS {
+	return &IOFS{disk: disk}
+}
+
+func (f *IOFS) Open(name string) (iofs.File, error) {
+	info, err := f.stat("open", name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return &ioDirectory{fs: f, name: name, info: info}, nil
+	}
+
+	stream, err := f.disk.ReadStream(f.path(name))
+
+	if err != nil {
+		return nil, f.error("open", name, err)
+	}
+
+	return &ioFile{disk: f.disk, name: f.path(name), info: info, reader: stream}, nil
+}
+
+func (f *IOFS) Stat(name string) (iofs.FileInfo, error) {
+	return f.stat("stat", name)
+}
+
+func (f *IOFS) ReadFile(name string) ([]byte, error) {
+	if !iofs.ValidPath(name) {
+		return nil, &iofs.PathError{Op: "readfile", Path: name, Err: iofs.ErrInvalid}
+	}
+
+	content, err := f.disk.Get(f.path(name))
+
+	if err != nil {
+		return nil, f.error("readfile", name, err)
+	}
+
+	return content, nil
+}
+
+func (f *IOFS) ReadDir(name string) ([]iofs.DirEntry, error) {
+	info, err := f.stat("readdir", name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, &iofs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	dir := f.path(name)
+	entries := make([]iofs.DirEntry, 0)
+	dirs, err := f.disk.ListDirectories(dir)
+
+	if err != nil {
+		return nil, f.error("readdir", name, err)
+	}
+
+	files, err := f.disk.List(dir)
+
+	if err != nil {
+		return nil, f.error("readdir", name, err)
+	}
+
+	for _, d := range dirs {
+		entries = append(entries, &ioEntry{info: &ioInfo{name: path.Base(d.Cwd()), dir: true}})
+	}
+
+	for _, file := range files {
+		entries = append(entries, &ioEntry{file: file})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+func (f *IOFS) stat(op string, name string) (*ioInfo, error) {
+	if !iofs.ValidPath(name) {
+		return nil, &iofs.PathError{Op: op, Path: name, Err: iofs.ErrInvalid}
+	}
+
+	if name == "." {
+		return &ioInfo{name: name, dir: true}, nil
+	}
+
+	a, err := f.disk.Stat(name)
+
+	if err != nil {
+		return nil, f.error(op, name, err)
+	}
+
+	if a.IsDir {
+		return &ioInfo{name: path.Base(name), dir: true}, nil
+	}
+
+	return &ioInfo{name: path.Base(name), size: a.Size, modified: a.LastModified}, nil
+}
+
+func (f *IOFS) error(op string, name string, err error) error {
+	return &iofs.PathError{Op: op, Path: name, Err: err}
+}
+
+func (f *IOFS) path(name string) string {
+	if name == "." {
+		return ""
+	}
+
+	return name
+}
+
+type ioInfo struct {
+	name     string
+	size     int64
+	modified int64
+	dir      bool
+}
+
+func (i *ioInfo) Name() string {
+	return i.name
+}
+
+func (i *ioInfo) Size() int64 {
+	return i.size
+}
+
+func (i *ioInfo) Mode() iofs.FileMode {
+	if i.dir {
+		return iofs.ModeDir | 0555
+	}
+
+	return 0444
+}
+
+func (i *ioInfo) ModTime() time.Time {
+	return time.Unix(i.modified, 0)
+}
+
+func (i *ioInfo) IsDir() bool {
+	return i.dir
+}
+
+func (i *ioInfo) Sys() any {
+	return nil
+}
+
+type ioEntry struct {
+	file *File
+	info *ioInfo
+}
+
+func (e *ioEntry) Name() string {
+	if e.file != nil {
+		return e.file.Name()
+	}
+
+	return e.info.Name()
+}
+
+func (e *ioEntry) IsDir() bool {
+	return e.file == nil
+}
+
+func (e *ioEntry) Type() iofs.FileMode {
+	if e.IsDir() {
+		return iofs.ModeDir
+	}
+
+	return 0
+}
+
+func (e *ioEntry) Info() (iofs.FileInfo, error) {
+	if e.info == nil {
+		e.info = &ioInfo{name: e.file.Name(), size: e.file.Size(), modified: e.file.LastModified()}
+	}
+
+	return e.info, nil
+}
+
+// ioFile streams the file from its beginning and reads the file in chunks with
+// GetRange once it was seeked elsewhere.
+type ioFile struct {
+	disk   Disk
+	name   string
+	info   *ioInfo
+	offset int64
+	reader io.ReadCloser
+}
+
+func (f *ioFile) Stat() (iofs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *ioFile) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for f.offset < f.info.size {
+		if f.reader == nil {
+			err := f.open()
+
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		n, err := f.reader.Read(p)
+		f.offset += int64(n)
+
+		if err == io.EOF {
+			f.Close()
+			err = nil
+		}
+
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+
+	return 0, io.EOF
+}
+
+func (f *ioFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.size
+	}
+
+	if offset < 0 {
+		return 0, &iofs.PathError{Op: "seek", Path: f.info.name, Err: iofs.ErrInvalid}
+	}
+
+	if offset != f.offset {
+		f.Close()
+	}
+
+	f.offset = offset
+
+	return offset, nil
+}
+
+func (f *ioFile) Close() error {
+	if f.reader == nil {
+		return nil
+	}
+
+	err := f.reader.Close()
+	f.reader = nil
+
+	return err
+}
+
+func (f *ioFile) open() error {
+	if f.offset == 0 {
+		reader, err := f.disk.ReadStream(f.name)
+		f.reader = reader
+
+		return err
+	}
+
+	length := f.info.size - f.offset
+
+	if length > chunkSize {
+		length = chunkSize
+	}
+
+	chunk, err := f.disk.GetRange(f.name, f.offset, length)
+
+	if err != nil {
+		return err
+	}
+
+	if len(chunk) == 0 {
+		return io.ErrUnexpectedEOF
+	}
+
+	f.reader = io.NopCloser(bytes.NewReader(chunk))
+
+	return nil
+}
+
+type ioDirectory struct {
+	fs      *IOFS
+	name    string
+	info    *ioInfo
+	entries []iofs.DirEntry
+	offset  int
+}
+
+func (d *ioDirectory) Stat() (iofs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *ioDirectory) Read(p []byte) (int, error) {
+	return 0, &iofs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *ioDirectory) Close() error {
+	return nil
+}
+
+func (d *ioDirectory) ReadDir(n int) ([]iofs.DirEntry, error) {
+	if d.entries == nil {
+		entries, err := d.fs.ReadDir(d.name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		d.entries = entries
+	}
+
+	rest := d.entries[d.offset:]
+
+	if n <= 0 {
+		d.offset = len(d.entries)
+
+		return rest, nil
+	}
+
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(rest) {
+		n = len(rest)
+	}
+
+	d.offset += n
+
+	return rest[:n], nil
+}
@@ -0,0 +1,39 @@
+package fs
+
+type PutOptions struct {
+	Visibility         Visibility
+	ContentType        string
+	CacheControl       string
+	ContentDisposition string
+	ContentEncoding    string
+	Metadata           map[string]string
+	// StorageClass and ServerSideEncryption are only supported by S3.
+	StorageClass         string
+	ServerSideEncryption string
+}
+
+type TransferOptions struct {
+	// Visibility overrides the visibility of the source file.
+	Visibility Visibility
+	// Move deletes the source file once it is transferred.
+	Move bool
+}
+
+// Overwrite decides what happens to existing files in the destination of
+// CopyDirectory and MoveDirectory.
+type Overwrite int
+
+const (
+	// OverwriteAlways replaces existing files.
+	OverwriteAlways Overwrite = iota
+	// OverwriteSkip keeps existing files. When moving, the skipped files stay in the source.
+	OverwriteSkip
+	// OverwriteFail reports an ErrExists error for every existing file.
+	OverwriteFail
+)
+
+type DirectoryOptions struct {
+	// Parallelism is the number of files copied at once, defaults to 1.
+	Parallelism int
+	Overwrite   Overwrite
+}
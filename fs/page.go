@@ -0,0 +1,9 @@
+package fs
+
+// Page is one page of a directory listing.
+type Page struct {
+	Files       []*File
+	Directories []Disk
+	// Next is the token of the following page, it is empty on the last page.
+	Next string
+}
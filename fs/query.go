@@ -0,0 +1,177 @@
+package fs
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// Query filters the files of a directory. The returned files carry their attributes.
+// Filters see the attributes of the listing, only files passing them are stat'ed
+// for WithMetadata or after Refresh.
+//
+//	files, err := disk.Query("reports").Recursive().Extension(".csv").ModifiedBefore(t).Get()
+type Query struct {
+	disk       Disk
+	dir        string
+	recursive  bool
+	extensions []string
+	filters    []func(Attributes) bool
+	wheres     []func(Attributes) bool
+	// metadata filters need the full attributes of a stat.
+	metadata []func(Attributes) bool
+	refresh  bool
+}
+
+func NewQuery(disk Disk, dir string) *Query {
+	return &Query{disk: disk, dir: dir}
+}
+
+// Recursive includes the files of all subdirectories.
+func (q *Query) Recursive() *Query {
+	q.recursive = true
+
+	return q
+}
+
+// Extension keeps files with one of the extensions, compared case-insensitively.
+func (q *Query) Extension(extensions ...string) *Query {
+	for _, ext := range extensions {
+		q.extensions = append(q.extensions, "."+strings.TrimPrefix(ext, "."))
+	}
+
+	return q
+}
+
+func (q *Query) ModifiedBefore(t time.Time) *Query {
+	return q.filter(func(a Attributes) bool {
+		return a.LastModified < t.Unix()
+	})
+}
+
+func (q *Query) ModifiedAfter(t time.Time) *Query {
+	return q.filter(func(a Attributes) bool {
+		return a.LastModified > t.Unix()
+	})
+}
+
+func (q *Query) LargerThan(size int64) *Query {
+	return q.filter(func(a Attributes) bool {
+		return a.Size > size
+	})
+}
+
+func (q *Query) SmallerThan(size int64) *Query {
+	return q.filter(func(a Attributes) bool {
+		return a.Size < size
+	})
+}
+
+func (q *Query) WithMetadata(key string, value string) *Query {
+	q.metadata = append(q.metadata, func(a Attributes) bool {
+		v, ok := a.Metadata[key]
+
+		return ok && v == value
+	})
+
+	return q
+}
+
+// Where keeps the files matching filter. filter sees the attributes of the
+// listing, which may lack headers and metadata unless Refresh is set.
+func (q *Query) Where(filter func(Attributes) bool) *Query {
+	q.wheres = append(q.wheres, filter)
+
+	return q
+}
+
+// Refresh stats the files passing the other filters before Where sees them.
+func (q *Query) Refresh() *Query {
+	q.refresh = true
+
+	return q
+}
+
+func (q *Query) filter(filter func(Attributes) bool) *Query {
+	q.filters = append(q.filters, filter)
+
+	return q
+}
+
+func (q *Query) Get() ([]*File, error) {
+	r := make([]*File, 0)
+	files, err := q.files()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if !q.hasExtension(file.Name()) {
+			continue
+		}
+
+		a, err := file.Stat()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !matches(q.filters, a) || !q.refresh && !matches(q.wheres, a) {
+			continue
+		}
+
+		if q.refresh || len(q.metadata) > 0 {
+			if a, err = file.Refresh(); err != nil {
+				return nil, err
+			}
+		}
+
+		if matches(q.metadata, a) && (!q.refresh || matches(q.wheres, a)) {
+			r = append(r, file)
+		}
+	}
+
+	return r, nil
+}
+
+func (q *Query) files() ([]*File, error) {
+	if !q.recursive {
+		return q.disk.List(q.dir)
+	}
+
+	files := make([]*File, 0)
+	err := q.disk.Walk(q.dir, func(entry Entry) error {
+		if !entry.IsDir() {
+			files = append(files, entry.File)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+func (q *Query) hasExtension(name string) bool {
+	if len(q.extensions) == 0 {
+		return true
+	}
+
+	for _, ext := range q.extensions {
+		if strings.EqualFold(path.Ext(name), ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matches(filters []func(Attributes) bool, a Attributes) bool {
+	for _, filter := range filters {
+		if !filter(a) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,43 @@
+package fs
+
+import (
+	iofs "io/fs"
+	"path"
+)
+
+// SkipDir can be returned by a WalkFunc to skip a directory. Returned for a file
+// it skips the remaining entries of the directory containing the file.
+var SkipDir = iofs.SkipDir
+
+type WalkFunc func(entry Entry) error
+
+// Entry is a file or a directory found by Walk or an Iterator. Path is relative to
+// the disk the walk started on.
+type Entry struct {
+	Path      string
+	File      *File
+	Directory Disk
+}
+
+func (e Entry) Name() string {
+	return path.Base(e.Path)
+}
+
+func (e Entry) IsDir() bool {
+	return e.Directory != nil
+}
+
+// Iterator walks a directory tree lazily, depth first.
+//
+//	it := disk.Iter("dir")
+//	defer it.Close()
+//	for it.Next() {
+//		entry := it.Entry()
+//	}
+//	err := it.Err()
+type Iterator interface {
+	Next() bool
+	Entry() Entry
+	Err() error
+	Close() error
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"errors"
+	"github.com/evolidev/storage/disk"
+	"github.com/evolidev/storage/fs"
+	"math"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSDisk(t *testing.T) {
+	t.Parallel()
+	storage := disk.NewFS(disk.FSConfig{FS: fstest.MapFS{
+		"files.txt":         {Data: []byte("test")},
+		"sub/files.txt":     {Data: []byte("test")},
+		"sub/sub/files.txt": {Data: []byte("test")},
+		"sub2/files.txt":    {Data: []byte("test")},
+	}})
+
+	t.Run("get should read file content", func(t *testing.T) {
+		content, err := storage.Get("sub/files.txt")
+
+		check(t, err, "Failed to read file %s", "sub/files.txt")
+		if string(content) != "test" {
+			t.Errorf("Content %s does not match %s", content, "test")
+		}
+	})
+
+	t.Run("get range should read part of file", func(t *testing.T) {
+		content, err := storage.GetRange("sub/files.txt", 1, 2)
+
+		check(t, err, "Failed to read file %s", "sub/files.txt")
+		if string(content) != "es" {
+			t.Errorf("Content %s does not match %s", content, "es")
+		}
+
+		content, err = storage.GetRange("sub/files.txt", 1, math.MaxInt64/2)
+
+		check(t, err, "Failed to read file %s", "sub/files.txt")
+		if string(content) != "est" {
+			t.Errorf("Content %s does not match %s", content, "est")
+		}
+	})
+
+	t.Run("exists and attributes should use the file system", func(t *testing.T) {
+		if !storage.Exists("sub2/files.txt") {
+			t.Errorf("File does not exists %s", "sub2/files.txt")
+		}
+
+		if !storage.Missing("sub2/not_existing.txt") {
+			t.Errorf("File should be missing %s", "sub2/not_existing.txt")
+		}
+
+		if storage.Size("files.txt") != 4 {
+			t.Errorf("%d does not match expected %d", storage.Size("files.txt"), 4)
+		}
+	})
+
+	t.Run("listing should return files and directories", func(t *testing.T) {
+		if len(storage.Files("")) != 1 {
+			t.Errorf("Expected count of %d does not match current %d", 1, len(storage.Files("")))
+		}
+
+		if len(storage.Directories("")) != 2 {
+			t.Errorf("Expected count of %d does not match current %d", 2, len(storage.Directories("")))
+		}
+
+		if len(storage.AllFiles("")) != 4 {
+			t.Errorf("Expected count of %d does not match current %d", 4, len(storage.AllFiles("")))
+		}
+	})
+
+	t.Run("listed files should be readable", func(t *testing.T) {
+		for _, d := range storage.Directories("") {
+			for _, file := range d.Files("") {
+				content, err := file.Get()
+
+				check(t, err, "Failed to read file %s", file.Name())
+				if string(content) != "test" {
+					t.Errorf("Content %s does not match %s", content, "test")
+				}
+			}
+		}
+	})
+
+	t.Run("prefix should scope the file system", func(t *testing.T) {
+		prefixed := storage.Prefix("sub")
+
+		if !prefixed.Exists("sub/files.txt") {
+			t.Errorf("failed to get file over prefix")
+		}
+
+		if len(prefixed.Files("")) != 1 {
+			t.Errorf("Expected count of %d does not match current %d", 1, len(prefixed.Files("")))
+		}
+	})
+
+	t.Run("writing should return a read-only error", func(t *testing.T) {
+		errs := []error{
+			storage.Put("files.txt", []byte("test"), fs.PUBLIC),
+			storage.Append("files.txt", []byte("test")),
+			storage.Delete("files.txt"),
+			storage.MakeDirectory("new", fs.PUBLIC),
+			storage.DeleteDirectory("sub"),
+			storage.SetVisibility("files.txt", fs.PRIVATE),
+		}
+
+		for _, err := range errs {
+			if !errors.Is(err, fs.ErrReadOnly) {
+				t.Errorf("Expected read-only error but got %s", err)
+			}
+
+			var e *fs.Error
+			if !errors.As(err, &e) {
+				t.Errorf("Expected *fs.Error but got %T", err)
+			}
+		}
+
+		if !storage.Exists("files.txt") {
+			t.Errorf("File got deleted")
+		}
+	})
+}
+
+func TestFSDiskPrefix(t *testing.T) {
+	t.Parallel()
+	storage := disk.NewFS(disk.FSConfig{FS: fstest.MapFS{
+		"sub/files.txt":     {Data: []byte("top")},
+		"sub/sub/files.txt": {Data: []byte("nested")},
+	}}).Prefix("sub")
+
+	if content, _ := storage.Get("sub/files.txt"); string(content) != "nested" {
+		t.Errorf("Content %s does not match %s", content, "nested")
+	}
+
+	files, err := storage.List("sub")
+	check(t, err, "Failed to list %s", "sub")
+
+	if len(files) != 1 {
+		t.Fatalf("Expected count of %d does not match current %d", 1, len(files))
+	}
+
+	if content, _ := files[0].Get(); string(content) != "nested" {
+		t.Errorf("Content %s does not match %s", content, "nested")
+	}
+}
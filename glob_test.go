@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"github.com/evolidev/storage/fs"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"avatars/*.png", "avatars/a.png", true},
+		{"avatars/*.png", "avatars/sub/a.png", false},
+		{"avatars/?.png", "avatars/ab.png", false},
+		{"avatars/[a-c].png", "avatars/b.png", true},
+		{"logs/**/2026-*.json", "logs/2026-01.json", true},
+		{"logs/**/2026-*.json", "logs/app/api/2026-01.json", true},
+		{"logs/**/2026-*.json", "logs/app/2025-01.json", false},
+		{"**", "any/file.txt", true},
+		{"**/*.txt", "file.txt", true},
+	}
+
+	for _, test := range tests {
+		match, err := fs.Match(test.pattern, test.name)
+
+		check(t, err, "Failed to match %s", test.pattern)
+		if match != test.match {
+			t.Errorf("%s matching %s should be %t", test.pattern, test.name, test.match)
+		}
+	}
+
+	if _, err := fs.Match("[a-", "a"); err != path.ErrBadPattern {
+		t.Errorf("Expected bad pattern error but got %s", err)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/glob should return matching files", func(t *testing.T) {
+			base := "glob"
+			d := setup(t, storage, base)
+			defer d()
+			tests := map[string]int{
+				base + "/*.txt":           1,
+				base + "/sub*/files.txt":  2,
+				base + "/**/files.txt":    4,
+				base + "/sub/**/*.txt":    2,
+				base + "/sub/?/files.txt": 0,
+				"glob/[s]ub2/files.[t]xt": 1,
+				"gl*/sub/sub/files.txt":   1,
+			}
+
+			for pattern, count := range tests {
+				files, err := storage.Glob(pattern)
+
+				check(t, err, "Failed to glob %s", pattern)
+				if len(files) != count {
+					t.Errorf("%s: expected count of %d does not match current %d", pattern, count, len(files))
+				}
+
+				for _, file := range files {
+					if content, _ := file.Get(); string(content) != "test" {
+						t.Errorf("%s: file %s is not readable", pattern, file.Name())
+					}
+				}
+			}
+		})
+
+		t.Run(name+"/glob should work on prefixed disks", func(t *testing.T) {
+			base := "glob_prefix"
+			d := setup(t, storage, base)
+			defer d()
+
+			files, err := storage.Prefix(base).Glob("sub/**/files.txt")
+
+			check(t, err, "Failed to glob")
+			if len(files) != 2 || !strings.HasSuffix(files[1].Path(), "sub/sub/files.txt") {
+				t.Errorf("Unexpected files %v", files)
+			}
+		})
+	}
+}
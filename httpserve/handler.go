@@ -0,0 +1,251 @@
+package httpserve
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/evolidev/storage/fs"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// chunkSize is the size of the ranges read from the disk once a request
+// does not start at the beginning of the file.
+const chunkSize = 4 * 1024 * 1024
+
+type Config struct {
+	// Listing enables HTML listings for directories.
+	Listing bool
+}
+
+// Handler serves the files of a disk with support for range and conditional requests.
+type Handler struct {
+	disk   fs.Disk
+	config Config
+}
+
+func NewHandler(disk fs.Disk, config Config) *Handler {
+	return &Handler{disk: disk, config: config}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	disk := h.disk.WithContext(r.Context())
+	name := strings.Trim(path.Clean("/"+r.URL.Path), "/")
+	a := fs.Attributes{IsDir: true}
+	var err error
+
+	if name != "" {
+		a, err = disk.Stat(name)
+	}
+
+	if err != nil {
+		httpError(w, err)
+
+		return
+	}
+
+	if a.IsDir {
+		h.list(w, r, disk, name)
+
+		return
+	}
+
+	header := w.Header()
+	headers := map[string]string{
+		"ETag":                a.ETag,
+		"Content-Type":        a.ContentType,
+		"Cache-Control":       a.CacheControl,
+		"Content-Disposition": a.ContentDisposition,
+		"Content-Encoding":    a.ContentEncoding,
+	}
+
+	for k, v := range headers {
+		if v != "" {
+			header.Set(k, v)
+		}
+	}
+
+	c := &content{disk: disk, name: name, size: a.Size}
+	defer c.Close()
+
+	http.ServeContent(w, r, name, time.Unix(a.LastModified, 0), c)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request, disk fs.Disk, dir string) {
+	if !h.config.Listing {
+		http.Error(w, "not found", http.StatusNotFound)
+
+		return
+	}
+
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		http.Redirect(w, r, path.Base(r.URL.Path)+"/", http.StatusMovedPermanently)
+
+		return
+	}
+
+	files, err := disk.List(dir)
+
+	if err != nil {
+		httpError(w, err)
+
+		return
+	}
+
+	dirs, err := disk.ListDirectories(dir)
+
+	if err != nil {
+		httpError(w, err)
+
+		return
+	}
+
+	names := make([]string, 0, len(files)+len(dirs))
+
+	for _, d := range dirs {
+		names = append(names, path.Base(d.Cwd())+"/")
+	}
+
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+
+	sort.Strings(names)
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+
+	for _, name := range names {
+		link := url.URL{Path: name}
+		fmt.Fprintf(buf, "<a href=\"%s\">%s</a>\n", link.String(), html.EscapeString(name))
+	}
+
+	buf.WriteString("</pre>\n")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if r.Method != http.MethodHead {
+		w.Write(buf.Bytes())
+	}
+}
+
+func httpError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotFound):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, "forbidden", http.StatusForbidden)
+	default:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	}
+}
+
+// content is a lazy io.ReadSeeker over a file. Reads from the start stream the
+// whole file, reads from other offsets fetch the file in chunks with GetRange.
+type content struct {
+	disk   fs.Disk
+	name   string
+	size   int64
+	offset int64
+	reader io.ReadCloser
+}
+
+func (c *content) Read(p []byte) (int, error) {
+	for c.offset < c.size {
+		if c.reader == nil {
+			err := c.open()
+
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		n, err := c.reader.Read(p)
+		c.offset += int64(n)
+
+		if err == io.EOF {
+			c.Close()
+			err = nil
+		}
+
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+
+	return 0, io.EOF
+}
+
+func (c *content) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += c.offset
+	case io.SeekEnd:
+		offset += c.size
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	if offset != c.offset {
+		c.Close()
+	}
+
+	c.offset = offset
+
+	return offset, nil
+}
+
+func (c *content) Close() error {
+	if c.reader == nil {
+		return nil
+	}
+
+	err := c.reader.Close()
+	c.reader = nil
+
+	return err
+}
+
+func (c *content) open() error {
+	if c.offset == 0 {
+		reader, err := c.disk.ReadStream(c.name)
+		c.reader = reader
+
+		return err
+	}
+
+	length := c.size - c.offset
+
+	if length > chunkSize {
+		length = chunkSize
+	}
+
+	chunk, err := c.disk.GetRange(c.name, c.offset, length)
+
+	if err != nil {
+		return err
+	}
+
+	if len(chunk) == 0 {
+		return io.ErrUnexpectedEOF
+	}
+
+	c.reader = io.NopCloser(bytes.NewReader(chunk))
+
+	return nil
+}
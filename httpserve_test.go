@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"github.com/evolidev/storage/httpserve"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPServe(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		base := "http_serve"
+		d := setup(t, storage, base)
+		handler := httpserve.NewHandler(storage.Prefix(base), httpserve.Config{})
+		listing := httpserve.NewHandler(storage.Prefix(base), httpserve.Config{Listing: true})
+
+		serve := func(handler http.Handler, target string, header map[string]string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodGet, target, nil)
+			for k, v := range header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			return w
+		}
+
+		t.Run(name+"/handler should serve files with etag and content type", func(t *testing.T) {
+			w := serve(handler, "/sub/files.txt", nil)
+
+			if w.Code != http.StatusOK || w.Body.String() != "test" {
+				t.Errorf("Unexpected response %d %s", w.Code, w.Body)
+			}
+
+			if w.Header().Get("ETag") == "" || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+				t.Errorf("Unexpected headers %v", w.Header())
+			}
+		})
+
+		t.Run(name+"/handler should serve ranges", func(t *testing.T) {
+			w := serve(handler, "/sub/files.txt", map[string]string{"Range": "bytes=1-2"})
+
+			if w.Code != http.StatusPartialContent || w.Body.String() != "es" {
+				t.Errorf("Unexpected response %d %s", w.Code, w.Body)
+			}
+
+			if w.Header().Get("Content-Range") != "bytes 1-2/4" {
+				t.Errorf("Unexpected content range %s", w.Header().Get("Content-Range"))
+			}
+		})
+
+		t.Run(name+"/handler should answer conditional requests", func(t *testing.T) {
+			etag := serve(handler, "/files.txt", nil).Header().Get("ETag")
+
+			w := serve(handler, "/files.txt", map[string]string{"If-None-Match": etag})
+			if w.Code != http.StatusNotModified {
+				t.Errorf("Expected status %d but got %d", http.StatusNotModified, w.Code)
+			}
+
+			since := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+			w = serve(handler, "/files.txt", map[string]string{"If-Modified-Since": since})
+			if w.Code != http.StatusNotModified {
+				t.Errorf("Expected status %d but got %d", http.StatusNotModified, w.Code)
+			}
+		})
+
+		t.Run(name+"/handler should return not found", func(t *testing.T) {
+			for _, target := range []string{"/not_existing.txt", "/sub/"} {
+				if w := serve(handler, target, nil); w.Code != http.StatusNotFound {
+					t.Errorf("%s: expected status %d but got %d", target, http.StatusNotFound, w.Code)
+				}
+			}
+		})
+
+		t.Run(name+"/handler should list directories", func(t *testing.T) {
+			w := serve(listing, "/", nil)
+			body := w.Body.String()
+
+			if w.Code != http.StatusOK || !strings.Contains(body, `<a href="files.txt">`) || !strings.Contains(body, `<a href="sub/">`) {
+				t.Errorf("Unexpected listing %d %s", w.Code, body)
+			}
+
+			if w := serve(listing, "/sub", nil); w.Code != http.StatusMovedPermanently {
+				t.Errorf("Expected status %d but got %d", http.StatusMovedPermanently, w.Code)
+			}
+		})
+
+		d()
+	}
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"errors"
+	"github.com/evolidev/storage/fs"
+	iofs "io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIOFS(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/io fs should behave like a standard file system", func(t *testing.T) {
+			base := "io_fs"
+			d := setup(t, storage, base)
+			defer d()
+
+			err := fstest.TestFS(fs.NewIOFS(storage.Prefix(base)), "files.txt", "sub/files.txt", "sub/sub/files.txt", "sub2/files.txt", "empty")
+
+			check(t, err, "io fs does not behave as expected")
+		})
+
+		t.Run(name+"/io fs should serve ranges with http file server", func(t *testing.T) {
+			base := "io_fs_http"
+			defer storage.DeleteDirectory(base)
+			storage.Put(base+"/files.txt", []byte("test"), fs.PUBLIC)
+			storage.Put(base+"/files", []byte("test"), fs.PUBLIC)
+			handler := http.FileServer(http.FS(fs.NewIOFS(storage.Prefix(base))))
+
+			r := httptest.NewRequest(http.MethodGet, "/files.txt", nil)
+			r.Header.Set("Range", "bytes=1-2")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != http.StatusPartialContent || w.Body.String() != "es" {
+				t.Errorf("Unexpected response %d %s", w.Code, w.Body)
+			}
+
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files", nil))
+
+			if w.Code != http.StatusOK || w.Body.String() != "test" {
+				t.Errorf("Unexpected response %d %s", w.Code, w.Body)
+			}
+		})
+
+		t.Run(name+"/io fs should return not exist for missing files", func(t *testing.T) {
+			base := "io_fs_missing"
+			d := setup(t, storage, base)
+			defer d()
+			fsys := fs.NewIOFS(storage.Prefix(base))
+
+			_, err := fsys.Open("not_existing.txt")
+			if !errors.Is(err, iofs.ErrNotExist) {
+				t.Errorf("Expected not exist error but got %s", err)
+			}
+
+			_, err = fsys.ReadFile("sub/not_existing.txt")
+			if !errors.Is(err, iofs.ErrNotExist) {
+				t.Errorf("Expected not exist error but got %s", err)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"errors"
 	"github.com/evolidev/storage/disk"
 	"github.com/evolidev/storage/fs"
+	"io"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -122,3 +125,155 @@ func TestDirectory(t *testing.T) {
 		}
 	})
 }
+
+func TestListShouldReturnError(t *testing.T) {
+	t.Parallel()
+	storage := disk.NewLocal(disk.LocalConfig{})
+
+	_, err := storage.List("local_list_not_existing")
+	if !errors.Is(err, fs.ErrNotFound) {
+		t.Errorf("Expected not found error but got %s", err)
+	}
+
+	_, err = storage.ListDirectories("local_list_not_existing")
+	if !errors.Is(err, fs.ErrNotFound) {
+		t.Errorf("Expected not found error but got %s", err)
+	}
+}
+
+func TestLocalAttributes(t *testing.T) {
+	t.Parallel()
+	storage := disk.NewLocal(disk.LocalConfig{})
+	base := "local_attributes"
+	defer storage.DeleteDirectory(base)
+	storage.Put(base+"/test.csv", []byte("a,b"), fs.PRIVATE)
+	os.WriteFile(base+"/.test.csv.meta.json", []byte(`{"content_type":"text/csv","metadata":{"owner":"test"}}`), 0644)
+
+	t.Run("stat should read metadata from the sidecar file", func(t *testing.T) {
+		a, err := storage.Stat(base + "/test.csv")
+
+		if err != nil {
+			t.Errorf("failed to stat file %s", err)
+		}
+
+		if a.ContentType != "text/csv" || a.Metadata["owner"] != "test" {
+			t.Errorf("metadata not read %+v", a)
+		}
+
+		if a.Visibility != fs.PRIVATE {
+			t.Errorf("wrong visibility %d", a.Visibility)
+		}
+	})
+
+	t.Run("put should store the checksum", func(t *testing.T) {
+		defer storage.DeleteDirectory("local_checksum")
+		storage.Put("local_checksum/files.txt", []byte("test"), fs.PUBLIC)
+		a, _ := storage.Stat("local_checksum/files.txt")
+		expected := "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+		if a.Checksum != expected {
+			t.Errorf("Expected checksum %s but got %s", expected, a.Checksum)
+		}
+	})
+
+	t.Run("stat should drop the checksum of files changed by others", func(t *testing.T) {
+		defer storage.DeleteDirectory("local_stale_checksum")
+		storage.Put("local_stale_checksum/files.txt", []byte("test"), fs.PUBLIC)
+		os.WriteFile("local_stale_checksum/files.txt", []byte("changed"), 0644)
+		a, _ := storage.Stat("local_stale_checksum/files.txt")
+
+		if a.Checksum != "" {
+			t.Errorf("Expected no checksum but got %s", a.Checksum)
+		}
+	})
+
+	t.Run("failed put stream should keep the file", func(t *testing.T) {
+		defer storage.DeleteDirectory("local_failed_put")
+		storage.Put("local_failed_put/files.txt", []byte("test"), fs.PUBLIC)
+		err := storage.PutStream("local_failed_put/files.txt", io.MultiReader(strings.NewReader("new"), failReader{}), fs.PutOptions{})
+
+		if err == nil {
+			t.Errorf("Expected an error for the failing reader")
+		}
+
+		if content, _ := storage.Get("local_failed_put/files.txt"); string(content) != "test" {
+			t.Errorf("Expected content %s but got %s", "test", content)
+		}
+
+		if files := storage.Files("local_failed_put"); len(files) != 1 {
+			t.Errorf("Expected count of %d does not match current %d", 1, len(files))
+		}
+	})
+
+	t.Run("sidecar files should not be listed", func(t *testing.T) {
+		files := storage.Files(base)
+
+		if len(files) != 1 {
+			t.Errorf("Expected count of %d does not match current %d", 1, len(files))
+		}
+	})
+
+	t.Run("stat should sniff the content type", func(t *testing.T) {
+		storage.Put(base+"/page", []byte("<html><body>test</body></html>"), fs.PUBLIC)
+
+		a, _ := storage.Stat(base + "/page")
+
+		if !strings.HasPrefix(a.ContentType, "text/html") {
+			t.Errorf("wrong content type %s", a.ContentType)
+		}
+
+		if a.Visibility != fs.PUBLIC {
+			t.Errorf("wrong visibility %d", a.Visibility)
+		}
+	})
+
+	t.Run("delete should remove the sidecar file", func(t *testing.T) {
+		storage.Delete(base + "/test.csv")
+
+		if _, err := os.Stat(base + "/.test.csv.meta.json"); err == nil {
+			t.Errorf("sidecar file still exists")
+		}
+	})
+}
+
+func TestLocalURL(t *testing.T) {
+	storage := disk.NewLocal(disk.LocalConfig{Prefix: "public", BaseURL: "https://example.com/storage"})
+
+	u, err := storage.URL("images/a b.png")
+
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	if u != "https://example.com/storage/public/images/a%20b.png" {
+		t.Errorf("wrong url %s", u)
+	}
+
+	_, err = disk.NewLocal(disk.LocalConfig{}).URL("images/a.png")
+	if !errors.Is(err, fs.ErrUnsupported) {
+		t.Errorf("Expected unsupported error but got %s", err)
+	}
+}
+
+func TestLocalPrefixShouldNotCollideWithPaths(t *testing.T) {
+	t.Parallel()
+	storage := disk.NewLocal(disk.LocalConfig{Prefix: "base"})
+	defer os.RemoveAll("base")
+
+	err := storage.Put("catalog/database.txt", []byte("test"), fs.PUBLIC)
+	check(t, err, "Failed to put %s", "catalog/database.txt")
+
+	if _, err := os.Stat("base/catalog/database.txt"); err != nil {
+		t.Errorf("File not stored below the prefix %s", err)
+	}
+
+	files := storage.AllFiles("")
+
+	if len(files) != 1 || files[0].Name() != "database.txt" {
+		t.Fatalf("Unexpected listing %v", files)
+	}
+
+	if content, err := files[0].Get(); err != nil || string(content) != "test" {
+		t.Errorf("Listed file not readable %s %s", content, err)
+	}
+}
@@ -2,15 +2,23 @@ package storage
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/evolidev/storage/disk"
 	"github.com/evolidev/storage/fs"
 	"io"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestS3Config(t *testing.T) {
@@ -123,6 +131,19 @@ func TestDeleteShouldPassError(t *testing.T) {
 	})
 }
 
+func TestDeleteShouldSucceedWithoutDeleteMarker(t *testing.T) {
+	c := disk.NewS3(disk.S3Config{Client: disk.NewMemoryClient()})
+	c.Put("test.txt", []byte("test"), fs.PUBLIC)
+
+	if err := c.Delete("test.txt"); err != nil {
+		t.Errorf("Expected no error but got %s", err)
+	}
+
+	if c.Exists("test.txt") {
+		t.Errorf("File still exists %s", "test.txt")
+	}
+}
+
 func TestListShouldPassError(t *testing.T) {
 	t.Parallel()
 	config := disk.S3Config{}
@@ -143,6 +164,687 @@ func TestListShouldPassError(t *testing.T) {
 		}
 	})
 
+	t.Run("List should return error", func(t *testing.T) {
+		_, err := c.List("test")
+		if err == nil {
+			t.Errorf("error expected")
+		}
+	})
+
+	t.Run("ListDirectories should return error", func(t *testing.T) {
+		_, err := c.ListDirectories("test")
+		if err == nil {
+			t.Errorf("error expected")
+		}
+	})
+
+	t.Run("Stat should return error", func(t *testing.T) {
+		_, err := c.Stat("test")
+		if !errors.Is(err, fs.ErrNotFound) {
+			t.Errorf("Expected not found error but got %s", err)
+		}
+	})
+
+}
+
+func TestPutStreamMultipart(t *testing.T) {
+	t.Run("streams larger than the part size should be uploaded in parts", func(t *testing.T) {
+		client := &multipartCount{MemoryClient: disk.NewMemoryClient()}
+		config := disk.S3Config{Client: client, PartSize: 4}
+		c := disk.NewS3(config)
+
+		err := c.PutStream("test.txt", strings.NewReader("multipart upload"), fs.PutOptions{})
+		if err != nil {
+			t.Errorf("unexpected error %s", err)
+		}
+
+		if client.parts != 4 {
+			t.Errorf("Expected %d parts but got %d", 4, client.parts)
+		}
+
+		content, _ := c.Get("test.txt")
+		if string(content) != "multipart upload" {
+			t.Errorf("Content %s does not match %s", content, "multipart upload")
+		}
+	})
+
+	t.Run("streams smaller than the part size should be uploaded at once", func(t *testing.T) {
+		client := &multipartCount{MemoryClient: disk.NewMemoryClient()}
+		config := disk.S3Config{Client: client, PartSize: 64}
+		c := disk.NewS3(config)
+
+		err := c.PutStream("test.txt", strings.NewReader("single"), fs.PutOptions{})
+		if err != nil {
+			t.Errorf("unexpected error %s", err)
+		}
+
+		if client.parts != 0 {
+			t.Errorf("Expected no parts but got %d", client.parts)
+		}
+	})
+
+	t.Run("failed uploads should be aborted", func(t *testing.T) {
+		client := &uploadPartFail{MemoryClient: disk.NewMemoryClient()}
+		config := disk.S3Config{Client: client, PartSize: 4}
+		c := disk.NewS3(config)
+
+		err := c.PutStream("test.txt", strings.NewReader("multipart upload"), fs.PutOptions{})
+		if err == nil {
+			t.Errorf("error expected")
+		}
+
+		if !client.aborted {
+			t.Errorf("upload not aborted")
+		}
+
+		if c.Exists("test.txt") {
+			t.Errorf("file should not exist")
+		}
+	})
+
+	t.Run("uploads should be aborted after the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		client := &uploadPartFail{MemoryClient: disk.NewMemoryClient(), cancel: cancel}
+		c := disk.NewS3(disk.S3Config{Client: client, PartSize: 4}).WithContext(ctx)
+
+		err := c.PutStream("test.txt", strings.NewReader("multipart upload"), fs.PutOptions{})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected cancelled error but got %v", err)
+		}
+
+		if !client.aborted || client.abortErr != nil {
+			t.Errorf("upload not aborted: %v", client.abortErr)
+		}
+	})
+}
+
+func TestPutOptionsShouldReachClient(t *testing.T) {
+	options := fs.PutOptions{
+		ContentType:          "text/plain",
+		StorageClass:         "STANDARD_IA",
+		ServerSideEncryption: "AES256",
+	}
+
+	for _, content := range []string{"single", "multipart upload"} {
+		client := disk.NewMemoryClient()
+		c := disk.NewS3(disk.S3Config{Client: client, PartSize: 8})
+
+		err := c.PutStream("test.txt", strings.NewReader(content), options)
+		if err != nil {
+			t.Errorf("unexpected error %s", err)
+		}
+
+		head, _ := client.HeadObject(context.TODO(), &s3.HeadObjectInput{Key: aws.String("test.txt")})
+
+		if aws.ToString(head.ContentType) != "text/plain" {
+			t.Errorf("wrong content type %s", aws.ToString(head.ContentType))
+		}
+
+		if head.StorageClass != types.StorageClassStandardIa {
+			t.Errorf("wrong storage class %s", head.StorageClass)
+		}
+
+		if head.ServerSideEncryption != types.ServerSideEncryptionAes256 {
+			t.Errorf("wrong encryption %s", head.ServerSideEncryption)
+		}
+	}
+}
+
+func TestPutShouldSendCannedACL(t *testing.T) {
+	client := disk.NewMemoryClient()
+	c := disk.NewS3(disk.S3Config{Client: client})
+	c.Put("public.txt", []byte("test"), fs.PUBLIC)
+	c.Put("private.txt", []byte("test"), fs.PRIVATE)
+
+	for file, public := range map[string]bool{"public.txt": true, "private.txt": false} {
+		o, err := client.GetObjectAcl(context.TODO(), &s3.GetObjectAclInput{Key: aws.String(file)})
+
+		if err != nil {
+			t.Errorf("unexpected error %s", err)
+		}
+
+		found := false
+		for _, grant := range o.Grants {
+			found = found || aws.ToString(grant.Grantee.URI) == "http://acs.amazonaws.com/groups/global/AllUsers"
+		}
+
+		if found != public {
+			t.Errorf("%s: expected public read grant %t", file, public)
+		}
+	}
+}
+
+func TestURL(t *testing.T) {
+	client := disk.NewMemoryClient()
+	tests := []struct {
+		config disk.S3Config
+		url    string
+	}{
+		{disk.S3Config{Bucket: "bucket"}, "https://bucket.s3.amazonaws.com/dir/file%20name.txt"},
+		{disk.S3Config{Bucket: "bucket", Region: "eu-central-1"}, "https://bucket.s3.eu-central-1.amazonaws.com/dir/file%20name.txt"},
+		{disk.S3Config{Bucket: "bucket", Endpoint: "http://localhost:9000", UsePathStyle: true}, "http://localhost:9000/bucket/dir/file%20name.txt"},
+		{disk.S3Config{Bucket: "bucket", Endpoint: "https://storage.example.com"}, "https://bucket.storage.example.com/dir/file%20name.txt"},
+		{disk.S3Config{Bucket: "bucket", BaseURL: "https://cdn.example.com/"}, "https://cdn.example.com/dir/file%20name.txt"},
+		{disk.S3Config{Bucket: "bucket", Prefix: "prefix"}, "https://bucket.s3.amazonaws.com/prefix/dir/file%20name.txt"},
+	}
+
+	for _, test := range tests {
+		test.config.Client = client
+		u, err := disk.NewS3(test.config).URL("dir/file name.txt")
+
+		if err != nil {
+			t.Errorf("unexpected error %s", err)
+		}
+
+		if u != test.url {
+			t.Errorf("URL %s does not match %s", u, test.url)
+		}
+	}
+
+	_, err := disk.NewMemory(disk.MemoryConfig{}).URL("file.txt")
+	if !errors.Is(err, fs.ErrUnsupported) {
+		t.Errorf("Expected unsupported error but got %s", err)
+	}
+}
+
+func TestTemporaryURLShouldBePresigned(t *testing.T) {
+	c := disk.NewS3(disk.S3Config{Bucket: "bucket", Region: "eu-central-1", Key: "key", Secret: "secret"})
+
+	u, err := c.TemporaryURL("dir/file.txt", 5*time.Minute)
+
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	if !strings.Contains(u, "/dir/file.txt?") || !strings.Contains(u, "X-Amz-Expires=300") || !strings.Contains(u, "X-Amz-Signature=") {
+		t.Errorf("url %s is not presigned", u)
+	}
+
+	_, err = disk.NewMemory(disk.MemoryConfig{}).TemporaryURL("file.txt", time.Minute)
+	if !errors.Is(err, fs.ErrUnsupported) {
+		t.Errorf("Expected unsupported error but got %s", err)
+	}
+}
+
+func TestTemporaryUploadURLShouldBePresigned(t *testing.T) {
+	c := disk.NewS3(disk.S3Config{Bucket: "bucket", Region: "eu-central-1", Key: "key", Secret: "secret"})
+
+	u, err := c.TemporaryUploadURL("dir/file.txt", 5*time.Minute, fs.PutOptions{Metadata: map[string]string{"owner": "test"}})
+
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	if !strings.Contains(u, "X-Amz-Signature=") || !strings.Contains(u, "x-id=PutObject") || !strings.Contains(u, "x-amz-meta-owner") {
+		t.Errorf("url %s is not presigned with the metadata", u)
+	}
+}
+
+func TestPostPolicy(t *testing.T) {
+	c := disk.NewS3(disk.S3Config{Bucket: "bucket", Region: "eu-central-1", Key: "key", Secret: "secret"})
+
+	p, err := c.PostPolicy("uploads/image.png", time.Hour, disk.PostPolicyOptions{
+		PutOptions: fs.PutOptions{ContentType: "image/", Metadata: map[string]string{"owner": "test"}},
+		MaxSize:    1024,
+	})
+
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	if p.URL != "https://bucket.s3.eu-central-1.amazonaws.com" || p.Fields["key"] != "uploads/image.png" || p.Fields["x-amz-meta-owner"] != "test" {
+		t.Errorf("unexpected policy %+v", p)
+	}
+
+	policy, _ := base64.StdEncoding.DecodeString(p.Fields["policy"])
+
+	for _, condition := range []string{`["content-length-range",0,1024]`, `["starts-with","$Content-Type","image/"]`, `{"bucket":"bucket"}`} {
+		if !strings.Contains(string(policy), condition) {
+			t.Errorf("condition %s missing in %s", condition, policy)
+		}
+	}
+
+	key := []byte("AWS4secret")
+	for _, part := range []string{p.Fields["x-amz-date"][:8], "eu-central-1", "s3", "aws4_request", p.Fields["policy"]} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+
+	if hex.EncodeToString(key) != p.Fields["x-amz-signature"] {
+		t.Errorf("wrong signature %s", p.Fields["x-amz-signature"])
+	}
+}
+
+func TestListShouldFollowContinuationTokens(t *testing.T) {
+	client := &listCount{MemoryClient: disk.NewMemoryClient()}
+	c := disk.NewS3(disk.S3Config{Client: client})
+
+	for i := 0; i < 2500; i++ {
+		client.PutObject(context.TODO(), &s3.PutObjectInput{Key: aws.String(fmt.Sprintf("many/%04d.txt", i)), Body: strings.NewReader("test")})
+	}
+
+	files, err := c.List("many")
+
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	if len(files) != 2500 {
+		t.Errorf("Expected count of %d does not match current %d", 2500, len(files))
+	}
+
+	if client.calls != 3 {
+		t.Errorf("Expected %d list calls but got %d", 3, client.calls)
+	}
+
+	page, _ := c.ListPage("many", "", 100)
+	next, _ := c.ListPage("many", page.Next, 100)
+
+	if len(page.Files) != 100 || next.Files[0].Name() != "0100.txt" {
+		t.Errorf("Unexpected pages %d %s", len(page.Files), next.Files[0].Name())
+	}
+}
+
+func TestMemoryClientDelimiter(t *testing.T) {
+	client := disk.NewMemoryClient()
+
+	for _, key := range []string{"a/1", "a/2", "b/x/1", "b/x/2", "b/y", "c.txt"} {
+		client.PutObject(context.TODO(), &s3.PutObjectInput{Key: aws.String(key), Body: strings.NewReader("test")})
+	}
+
+	list := func(prefix string, token *string, limit int32) ([]string, *s3.ListObjectsV2Output) {
+		o, err := client.ListObjectsV2(context.TODO(), &s3.ListObjectsV2Input{
+			Prefix:            aws.String(prefix),
+			Delimiter:         aws.String("/"),
+			ContinuationToken: token,
+			MaxKeys:           limit,
+		})
+
+		if err != nil {
+			t.Errorf("unexpected error %s", err)
+		}
+
+		entries := make([]string, 0)
+		for _, p := range o.CommonPrefixes {
+			entries = append(entries, *p.Prefix)
+		}
+		for _, object := range o.Contents {
+			entries = append(entries, *object.Key)
+		}
+
+		return entries, o
+	}
+
+	first, o := list("", nil, 2)
+	second, _ := list("", o.NextContinuationToken, 2)
+
+	if strings.Join(first, ",") != "a/,b/" || strings.Join(second, ",") != "c.txt" {
+		t.Errorf("Unexpected pages %v %v", first, second)
+	}
+
+	if entries, _ := list("b/", nil, 0); strings.Join(entries, ",") != "b/x/,b/y" {
+		t.Errorf("Unexpected entries %v", entries)
+	}
+}
+
+func TestListShouldOnlyFetchOneLevel(t *testing.T) {
+	client := &delimiterRecorder{MemoryClient: disk.NewMemoryClient()}
+	c := disk.NewS3(disk.S3Config{Client: client})
+
+	for i := 0; i < 50; i++ {
+		c.Put(fmt.Sprintf("deep/sub/%d/files.txt", i), []byte("test"), fs.PUBLIC)
+	}
+	c.Put("deep/files.txt", []byte("test"), fs.PUBLIC)
+
+	dirs, _ := c.ListDirectories("deep")
+	files, _ := c.List("deep")
+
+	if len(dirs) != 1 || len(files) != 1 {
+		t.Errorf("Unexpected listing %d directories, %d files", len(dirs), len(files))
+	}
+
+	if client.delimiter != "/" || client.keys != 2 {
+		t.Errorf("Expected delimiter listing but got delimiter %q and %d keys", client.delimiter, client.keys)
+	}
+}
+
+func TestGlobShouldListLiteralPrefix(t *testing.T) {
+	client := &prefixRecorder{MemoryClient: disk.NewMemoryClient()}
+	c := disk.NewS3(disk.S3Config{Client: client, Prefix: "root"})
+	c.Put("avatars/ab.png", []byte("test"), fs.PUBLIC)
+	c.Put("avatars/ac.png", []byte("test"), fs.PUBLIC)
+	c.Put("avatars/b.png", []byte("test"), fs.PUBLIC)
+
+	files, err := c.Glob("avatars/a*.png")
+
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	if len(files) != 2 {
+		t.Errorf("Expected count of %d does not match current %d", 2, len(files))
+	}
+
+	if client.prefix != "root/avatars/a" {
+		t.Errorf("Expected prefix %s but got %s", "root/avatars/a", client.prefix)
+	}
+}
+
+func TestListedFilesShouldNotStat(t *testing.T) {
+	client := &headCount{MemoryClient: disk.NewMemoryClient()}
+	c := disk.NewS3(disk.S3Config{Client: client})
+
+	for i := 0; i < 10; i++ {
+		c.PutWithOptions(fmt.Sprintf("listed/%d.txt", i), []byte("test"), fs.PutOptions{ContentType: "text/plain"})
+	}
+
+	files, _ := c.List("listed")
+
+	for _, file := range files {
+		if file.Size() != 4 || file.LastModified() == 0 {
+			t.Errorf("Wrong attributes for %s", file.Name())
+		}
+	}
+
+	if client.count != 0 {
+		t.Errorf("Expected no HeadObject calls but got %d", client.count)
+	}
+
+	a, _ := files[0].Refresh()
+
+	if client.count != 1 || a.ContentType != "text/plain" {
+		t.Errorf("Refresh should stat the file, got %d calls and %+v", client.count, a)
+	}
+}
+
+func TestCopyShouldStayOnServer(t *testing.T) {
+	client := &copyRecorder{MemoryClient: disk.NewMemoryClient()}
+	c := disk.NewS3(disk.S3Config{Client: client, Bucket: "bucket"})
+	c.PutWithOptions("a b.txt", []byte("test"), fs.PutOptions{Visibility: fs.PUBLIC, ContentType: "text/plain"})
+
+	err := c.Move("a b.txt", "moved.txt")
+
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	if client.gets != 0 || client.source != "bucket/a%20b.txt" {
+		t.Errorf("Expected server side copy of %s but got %d downloads", client.source, client.gets)
+	}
+
+	a, _ := c.Stat("moved.txt")
+	visibility, _ := c.Visibility("moved.txt")
+
+	if a.ContentType != "text/plain" || visibility != fs.PUBLIC || c.Exists("a b.txt") {
+		t.Errorf("Unexpected move result %+v %v", a, visibility)
+	}
+}
+
+func TestCopyShouldCopyLargeObjectsInParts(t *testing.T) {
+	client := &copyRecorder{MemoryClient: disk.NewMemoryClient()}
+	c := disk.NewS3(disk.S3Config{Client: client, CopyPartSize: 4})
+	c.PutWithOptions("large.txt", []byte("0123456789"), fs.PutOptions{Metadata: map[string]string{"owner": "test"}})
+
+	err := c.Copy("large.txt", "copy.txt")
+
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	content, _ := c.Get("copy.txt")
+	a, _ := c.Stat("copy.txt")
+
+	if string(content) != "0123456789" || a.Metadata["owner"] != "test" {
+		t.Errorf("Unexpected copy %s %+v", content, a)
+	}
+
+	if strings.Join(client.ranges, ",") != "bytes=0-3,bytes=4-7,bytes=8-9" {
+		t.Errorf("Unexpected ranges %v", client.ranges)
+	}
+}
+
+func TestPrefixShouldNotCollideWithKeys(t *testing.T) {
+	d := disk.NewMemory(disk.MemoryConfig{})
+	p := d.Prefix("logs")
+	d.Put("logs/x.txt", []byte("top"), fs.PUBLIC)
+	p.Put("logs/x.txt", []byte("nested"), fs.PUBLIC)
+
+	if content, _ := d.Get("logs/x.txt"); string(content) != "top" || !d.Exists("logs/logs/x.txt") {
+		t.Errorf("Prefixed put overwrote %s", "logs/x.txt")
+	}
+
+	files, _ := p.List("logs")
+	globbed, _ := p.Glob("logs/*.txt")
+
+	for _, list := range [][]*fs.File{files, globbed} {
+		if len(list) != 1 {
+			t.Fatalf("Expected count of %d does not match current %d", 1, len(list))
+		}
+
+		if content, _ := list[0].Get(); string(content) != "nested" {
+			t.Errorf("Listed file read %s", content)
+		}
+	}
+
+	check(t, p.DeleteDirectory("logs"), "Failed to delete directory %s", "logs")
+
+	if !d.Exists("logs/x.txt") || d.Exists("logs/logs/x.txt") {
+		t.Errorf("DeleteDirectory removed the wrong files")
+	}
+}
+
+func TestWalkShouldVisitDirectoriesOnce(t *testing.T) {
+	c := disk.NewMemory(disk.MemoryConfig{})
+	c.MakeDirectory("d", fs.PUBLIC)
+	c.Put("d/x.txt", []byte("test"), fs.PUBLIC)
+
+	// the marker "d" and the prefix "d/" end up on different pages
+	for i := 0; i < 999; i++ {
+		c.Put(fmt.Sprintf("d-%03d", i), []byte("test"), fs.PUBLIC)
+	}
+
+	files := make([]string, 0)
+
+	for _, file := range c.AllFiles("") {
+		if strings.HasPrefix(file.Name(), "x") {
+			files = append(files, file.Name())
+		}
+	}
+
+	if len(files) != 1 {
+		t.Errorf("Expected count of %d does not match current %d", 1, len(files))
+	}
+}
+
+func TestQueryShouldOnlyStatMatchingFiles(t *testing.T) {
+	client := &headCount{MemoryClient: disk.NewMemoryClient()}
+	c := disk.NewS3(disk.S3Config{Client: client})
+
+	for i := 0; i < 10; i++ {
+		c.PutWithOptions(fmt.Sprintf("query/%d.txt", i), []byte(strings.Repeat("a", i+1)), fs.PutOptions{
+			ContentType: "text/plain",
+			Metadata:    map[string]string{"owner": "test"},
+		})
+	}
+
+	files, _ := c.Query("query").Where(func(a fs.Attributes) bool { return a.Size > 3 }).Get()
+
+	if len(files) != 7 || client.count != 0 {
+		t.Errorf("Expected 7 files without HeadObject calls but got %d files and %d calls", len(files), client.count)
+	}
+
+	files, _ = c.Query("query").LargerThan(7).WithMetadata("owner", "test").Get()
+
+	if len(files) != 3 || client.count != 3 {
+		t.Errorf("Expected 3 files and 3 HeadObject calls but got %d files and %d calls", len(files), client.count)
+	}
+
+	client.count = 0
+	files, _ = c.Query("query").SmallerThan(3).Refresh().Where(func(a fs.Attributes) bool {
+		return a.ContentType == "text/plain"
+	}).Get()
+
+	if len(files) != 2 || client.count != 2 {
+		t.Errorf("Expected 2 files and 2 HeadObject calls but got %d files and %d calls", len(files), client.count)
+	}
+}
+
+func TestStatShouldNotFetchACL(t *testing.T) {
+	client := &aclCount{MemoryClient: disk.NewMemoryClient()}
+	c := disk.NewS3(disk.S3Config{Client: client})
+	c.Put("test.txt", []byte("test"), fs.PRIVATE)
+
+	c.Stat("test.txt")
+
+	if client.count != 0 {
+		t.Errorf("Expected no GetObjectAcl calls but got %d", client.count)
+	}
+
+	c.Append("test.txt", []byte("test"))
+
+	if v, _ := c.Visibility("test.txt"); v != fs.PRIVATE || client.count != 2 {
+		t.Errorf("Append should keep the visibility, got %o with %d calls", v, client.count)
+	}
+}
+
+func TestGetRangeShouldSendRangeHeader(t *testing.T) {
+	client := &rangeRecorder{MemoryClient: disk.NewMemoryClient()}
+	c := disk.NewS3(disk.S3Config{Client: client})
+	c.Put("test.txt", []byte("range test"), fs.PUBLIC)
+
+	content, err := c.GetRange("test.txt", 6, 4)
+
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	if client.header != "bytes=6-9" {
+		t.Errorf("Range header %s does not match %s", client.header, "bytes=6-9")
+	}
+
+	if string(content) != "test" {
+		t.Errorf("Content %s does not match %s", content, "test")
+	}
+}
+
+func TestMemoryClientRange(t *testing.T) {
+	client := disk.NewMemoryClient()
+	client.PutObject(context.TODO(), &s3.PutObjectInput{Key: aws.String("test.txt"), Body: strings.NewReader("range test")})
+
+	tests := map[string]string{
+		"bytes=0-4":  "range",
+		"bytes=6-":   "test",
+		"bytes=-2":   "st",
+		"bytes=8-20": "st",
+	}
+
+	for header, expected := range tests {
+		o, err := client.GetObject(context.TODO(), &s3.GetObjectInput{Key: aws.String("test.txt"), Range: aws.String(header)})
+
+		if err != nil {
+			t.Errorf("unexpected error %s for %s", err, header)
+			continue
+		}
+
+		content, _ := io.ReadAll(o.Body)
+		if string(content) != expected {
+			t.Errorf("Content %s does not match %s for %s", content, expected, header)
+		}
+	}
+
+	_, err := client.GetObject(context.TODO(), &s3.GetObjectInput{Key: aws.String("test.txt"), Range: aws.String("bytes=20-")})
+	if err == nil {
+		t.Errorf("error expected for unsatisfiable range")
+	}
+}
+
+func TestContextShouldReachClient(t *testing.T) {
+	client := &contextRecorder{MemoryClient: disk.NewMemoryClient()}
+	c := disk.NewS3(disk.S3Config{Client: client})
+	ctx := context.WithValue(context.Background(), contextKey("key"), "value")
+
+	c.WithContext(ctx).Get("test.txt")
+
+	if client.ctx.Value(contextKey("key")) != "value" {
+		t.Errorf("context did not reach the client")
+	}
+}
+
+func TestMemoryClientShouldHonourCancelledContext(t *testing.T) {
+	c := disk.NewMemory(disk.MemoryConfig{})
+	c.Put("test.txt", []byte("test"), fs.PUBLIC)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.WithContext(ctx).Get("test.txt")
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context error but got %s", err)
+	}
+}
+
+func TestErrorsShouldKeepOriginalError(t *testing.T) {
+	t.Run("not found should keep the s3 error", func(t *testing.T) {
+		c := disk.NewS3(disk.S3Config{Client: disk.NewMemoryClient()})
+
+		_, err := c.Get("not_existing.txt")
+
+		var noSuchKey *types.NoSuchKey
+		if !errors.As(err, &noSuchKey) {
+			t.Errorf("Expected NoSuchKey but got %s", err)
+		}
+	})
+
+	t.Run("access denied should return permission error", func(t *testing.T) {
+		c := disk.NewS3(disk.S3Config{Client: &accessDenied{MemoryClient: disk.NewMemoryClient()}})
+
+		_, err := c.Get("test.txt")
+
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("Expected permission error but got %s", err)
+		}
+
+		var apiErr smithy.APIError
+		if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "AccessDenied" {
+			t.Errorf("Expected AccessDenied but got %s", err)
+		}
+	})
+}
+
+func TestStatShouldReturnObjectMetadata(t *testing.T) {
+	client := disk.NewMemoryClient()
+	c := disk.NewS3(disk.S3Config{Client: client})
+	client.PutObject(context.TODO(), &s3.PutObjectInput{
+		Key:            aws.String("test.csv"),
+		Body:           strings.NewReader("a,b"),
+		ContentType:    aws.String("text/csv"),
+		Metadata:       map[string]string{"owner": "test"},
+		StorageClass:   types.StorageClassStandardIa,
+		ChecksumSHA256: aws.String("n4bQgYhMfWWaL+qgxVrQFaO/TxsrC4Is0V1sFbDwCgg="),
+	})
+
+	a, err := c.Stat("test.csv")
+
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	if a.ContentType != "text/csv" || a.Metadata["owner"] != "test" {
+		t.Errorf("metadata not returned %+v", a)
+	}
+
+	if a.StorageClass != "STANDARD_IA" {
+		t.Errorf("wrong storage class %s", a.StorageClass)
+	}
+
+	if a.Checksum != "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" {
+		t.Errorf("wrong checksum %s", a.Checksum)
+	}
+
+	if a.ETag == "" {
+		t.Errorf("etag missing")
+	}
 }
 
 type headFallback struct {
@@ -180,22 +882,179 @@ func (d *deleteFail) DeleteObject(ctx context.Context, params *s3.DeleteObjectIn
 	return nil, errors.New("fail")
 }
 
-func (d *deleteFail) ListObjects(ctx context.Context, params *s3.ListObjectsInput, optFns ...func(*s3.Options)) (*s3.ListObjectsOutput, error) {
+func (d *deleteFail) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
 	r := make([]types.Object, 0)
-	t := "test"
+	t := "test/test"
 
-	r = append(r, types.Object{Key: &t, Size: d.size})
+	if aws.ToString(params.Prefix) == "test/" {
+		r = append(r, types.Object{Key: &t, Size: d.size})
+	}
 
-	o := &s3.ListObjectsOutput{}
+	o := &s3.ListObjectsV2Output{}
 	o.Contents = r
 
 	return o, nil
 }
 
+type listCount struct {
+	calls int
+	*disk.MemoryClient
+}
+
+func (l *listCount) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	l.calls++
+
+	return l.MemoryClient.ListObjectsV2(ctx, params, optFns...)
+}
+
+type delimiterRecorder struct {
+	delimiter string
+	keys      int32
+	*disk.MemoryClient
+}
+
+func (d *delimiterRecorder) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	o, err := d.MemoryClient.ListObjectsV2(ctx, params, optFns...)
+	d.delimiter = aws.ToString(params.Delimiter)
+	d.keys = o.KeyCount
+
+	return o, err
+}
+
+type copyRecorder struct {
+	gets   int
+	source string
+	ranges []string
+	*disk.MemoryClient
+}
+
+func (c *copyRecorder) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	c.gets++
+
+	return c.MemoryClient.GetObject(ctx, params, optFns...)
+}
+
+func (c *copyRecorder) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	c.source = aws.ToString(params.CopySource)
+
+	return c.MemoryClient.CopyObject(ctx, params, optFns...)
+}
+
+func (c *copyRecorder) UploadPartCopy(ctx context.Context, params *s3.UploadPartCopyInput, optFns ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error) {
+	c.ranges = append(c.ranges, aws.ToString(params.CopySourceRange))
+
+	return c.MemoryClient.UploadPartCopy(ctx, params, optFns...)
+}
+
+type headCount struct {
+	count int
+	*disk.MemoryClient
+}
+
+func (h *headCount) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	h.count++
+
+	return h.MemoryClient.HeadObject(ctx, params, optFns...)
+}
+
+type prefixRecorder struct {
+	prefix string
+	*disk.MemoryClient
+}
+
+func (p *prefixRecorder) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	p.prefix = aws.ToString(params.Prefix)
+
+	return p.MemoryClient.ListObjectsV2(ctx, params, optFns...)
+}
+
 type listFail struct {
 	*disk.MemoryClient
 }
 
-func (l *listFail) ListObjects(ctx context.Context, params *s3.ListObjectsInput, optFns ...func(*s3.Options)) (*s3.ListObjectsOutput, error) {
+func (l *listFail) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
 	return nil, errors.New("fail")
 }
+
+type multipartCount struct {
+	parts int
+	*disk.MemoryClient
+}
+
+func (m *multipartCount) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	m.parts++
+
+	return m.MemoryClient.UploadPart(ctx, params, optFns...)
+}
+
+type uploadPartFail struct {
+	aborted  bool
+	abortErr error
+	cancel   context.CancelFunc
+	*disk.MemoryClient
+}
+
+func (u *uploadPartFail) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	if params.PartNumber > 1 && u.cancel != nil {
+		u.cancel()
+
+		return nil, ctx.Err()
+	}
+
+	if params.PartNumber > 1 {
+		return nil, errors.New("fail")
+	}
+
+	return u.MemoryClient.UploadPart(ctx, params, optFns...)
+}
+
+func (u *uploadPartFail) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	u.aborted = true
+	o, err := u.MemoryClient.AbortMultipartUpload(ctx, params, optFns...)
+	u.abortErr = err
+
+	return o, err
+}
+
+type rangeRecorder struct {
+	header string
+	*disk.MemoryClient
+}
+
+func (r *rangeRecorder) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	if params.Range != nil {
+		r.header = *params.Range
+	}
+
+	return r.MemoryClient.GetObject(ctx, params, optFns...)
+}
+
+type contextRecorder struct {
+	ctx context.Context
+	*disk.MemoryClient
+}
+
+func (c *contextRecorder) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	c.ctx = ctx
+
+	return c.MemoryClient.GetObject(ctx, params, optFns...)
+}
+
+type aclCount struct {
+	count int
+	*disk.MemoryClient
+}
+
+func (a *aclCount) GetObjectAcl(ctx context.Context, params *s3.GetObjectAclInput, optFns ...func(*s3.Options)) (*s3.GetObjectAclOutput, error) {
+	a.count++
+
+	return a.MemoryClient.GetObjectAcl(ctx, params, optFns...)
+}
+
+type accessDenied struct {
+	*disk.MemoryClient
+}
+
+func (a *accessDenied) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return nil, &smithy.GenericAPIError{Code: "AccessDenied", Message: "Access Denied"}
+}
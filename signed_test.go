@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"github.com/evolidev/storage/disk"
+	"github.com/evolidev/storage/fs"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemporaryURL(t *testing.T) {
+	t.Parallel()
+	key := []byte("secret")
+	disks := map[string]fs.Disk{
+		"memory":          disk.NewMemory(disk.MemoryConfig{BaseURL: "http://example.com/files", SignKey: key}),
+		"memory_prefixed": disk.NewMemory(disk.MemoryConfig{Prefix: "temporary_url", BaseURL: "http://example.com/files", SignKey: key}),
+		"local":           disk.NewLocal(disk.LocalConfig{Prefix: "temporary_url", BaseURL: "http://example.com/files", SignKey: key}),
+	}
+	defer os.RemoveAll("temporary_url")
+
+	for name, d := range disks {
+		d.Put("sub/files.txt", []byte("test"), fs.PUBLIC)
+		handler := http.StripPrefix("/files", disk.NewSignedHandler(d, key))
+
+		t.Run(name+"/temporary url should serve the file", func(t *testing.T) {
+			u, err := d.TemporaryURL("sub/files.txt", time.Minute)
+			check(t, err, "Failed to create temporary url")
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, u, nil))
+			content, _ := io.ReadAll(w.Body)
+
+			if w.Code != http.StatusOK || string(content) != "test" {
+				t.Errorf("Unexpected response %d %s", w.Code, content)
+			}
+		})
+
+		t.Run(name+"/expired or tampered urls should be rejected", func(t *testing.T) {
+			expired, _ := d.TemporaryURL("sub/files.txt", -time.Minute)
+			valid, _ := d.TemporaryURL("sub/files.txt", time.Minute)
+			tampered, _ := url.Parse(valid)
+			tampered.Path += ".bak"
+
+			for _, u := range []string{expired, tampered.String()} {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, u, nil))
+
+				if w.Code != http.StatusForbidden {
+					t.Errorf("Expected status %d but got %d", http.StatusForbidden, w.Code)
+				}
+			}
+		})
+
+		t.Run(name+"/temporary upload url should store the file", func(t *testing.T) {
+			options := fs.PutOptions{Visibility: fs.PRIVATE, ContentType: "text/csv", Metadata: map[string]string{"owner": "test"}}
+			u, err := d.TemporaryUploadURL("upload/test.csv", time.Minute, options)
+			check(t, err, "Failed to create temporary upload url")
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, u, strings.NewReader("a,b")))
+
+			if w.Code != http.StatusOK {
+				t.Errorf("Unexpected response %d %s", w.Code, w.Body)
+			}
+
+			a, err := d.Stat("upload/test.csv")
+			check(t, err, "Failed to stat uploaded file")
+
+			if a.Size != 3 || a.ContentType != "text/csv" || a.Metadata["owner"] != "test" {
+				t.Errorf("Wrong attributes %+v", a)
+			}
+
+			if v, _ := d.Visibility("upload/test.csv"); v != fs.PRIVATE {
+				t.Errorf("Expected private visibility but got %o", v)
+			}
+		})
+
+		t.Run(name+"/upload urls should not be usable for downloads and the other way around", func(t *testing.T) {
+			upload, _ := d.TemporaryUploadURL("sub/files.txt", time.Minute, fs.PutOptions{})
+			download, _ := d.TemporaryURL("sub/files.txt", time.Minute)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, upload, nil))
+			if w.Code != http.StatusForbidden {
+				t.Errorf("Expected status %d but got %d", http.StatusForbidden, w.Code)
+			}
+
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, download, strings.NewReader("changed")))
+			if w.Code != http.StatusForbidden {
+				t.Errorf("Expected status %d but got %d", http.StatusForbidden, w.Code)
+			}
+		})
+
+		t.Run(name+"/handler without key should reject every url", func(t *testing.T) {
+			u, _ := d.TemporaryURL("sub/files.txt", time.Minute)
+			w := httptest.NewRecorder()
+			http.StripPrefix("/files", disk.NewSignedHandler(d, nil)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, u, nil))
+
+			if w.Code != http.StatusForbidden {
+				t.Errorf("Expected status %d but got %d", http.StatusForbidden, w.Code)
+			}
+		})
+
+		t.Run(name+"/missing files should return not found", func(t *testing.T) {
+			u, _ := d.TemporaryURL("sub/not_existing.txt", time.Minute)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, u, nil))
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("Expected status %d but got %d", http.StatusNotFound, w.Code)
+			}
+		})
+	}
+}
+
+func TestTemporaryUploadURLShouldLimitSize(t *testing.T) {
+	t.Parallel()
+	key := []byte("secret")
+	d := disk.NewMemory(disk.MemoryConfig{BaseURL: "http://example.com/files", SignKey: key, MaxUploadSize: 2})
+	handler := http.StripPrefix("/files", disk.NewSignedHandler(d, key))
+	u, err := d.TemporaryUploadURL("upload/test.csv", time.Minute, fs.PutOptions{})
+	check(t, err, "Failed to create temporary upload url")
+
+	sized := httptest.NewRequest(http.MethodPut, u, strings.NewReader("a,b"))
+	streamed := httptest.NewRequest(http.MethodPut, u, io.MultiReader(strings.NewReader("a,b")))
+	streamed.ContentLength = -1
+
+	for _, r := range []*http.Request{sized, streamed} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status %d but got %d", http.StatusRequestEntityTooLarge, w.Code)
+		}
+	}
+
+	if d.Exists("upload/test.csv") {
+		t.Errorf("File should not be stored")
+	}
+}
@@ -1,10 +1,19 @@
 package storage
 
-import "github.com/evolidev/storage/fs"
+import (
+	"context"
+	"errors"
+	"github.com/evolidev/storage/disk"
+	"github.com/evolidev/storage/fs"
+	"io"
+	"strings"
+	"time"
+)
 
 type Storage struct {
 	disks    map[string]fs.Disk
 	fallback string
+	ctx      context.Context
 }
 
 func New(disks map[string]fs.Disk) *Storage {
@@ -24,21 +33,47 @@ func (s *Storage) AddDisk(name string, disk fs.Disk) {
 }
 
 func (s *Storage) Disk(name string) fs.Disk {
-	return s.disks[name]
+	d, ok := s.disks[name]
+
+	if ok && s.ctx != nil {
+		return d.WithContext(s.ctx)
+	}
+
+	return d
 }
 
 func (s *Storage) Attributes(file string) fs.Attributes {
 	return s.disk().Attributes(file)
 }
 
+func (s *Storage) Stat(file string) (fs.Attributes, error) {
+	return s.disk().Stat(file)
+}
+
 func (s *Storage) Put(file string, content []byte, visibility fs.Visibility) error {
 	return s.disk().File(file).Put(content, visibility)
 }
 
+func (s *Storage) PutWithOptions(file string, content []byte, options fs.PutOptions) error {
+	return s.disk().File(file).PutWithOptions(content, options)
+}
+
+func (s *Storage) PutStream(file string, content io.Reader, options fs.PutOptions) error {
+	return s.disk().File(file).PutStream(content, options)
+}
+
 func (s *Storage) Get(file string) ([]byte, error) {
 	return s.disk().File(file).Get()
 }
 
+func (s *Storage) ReadStream(file string) (io.ReadCloser, error) {
+	return s.disk().File(file).ReadStream()
+}
+
+func (s *Storage) GetRange(file string, offset int64, length int64) ([]byte, error) {
+	return s.disk().GetRange(file, offset, length)
+}
+
 func (s *Storage) Exists(file string) bool {
 	return s.disk().Exists(file)
 }
@@ -55,6 +90,18 @@ func (s *Storage) LastModified(file string) int64 {
 	return s.disk().File(file).LastModified()
 }
 
+func (s *Storage) URL(file string) (string, error) {
+	return s.disk().File(file).URL()
+}
+
+func (s *Storage) TemporaryURL(file string, expires time.Duration) (string, error) {
+	return s.disk().File(file).TemporaryURL(expires)
+}
+
+func (s *Storage) TemporaryUploadURL(file string, expires time.Duration, options fs.PutOptions) (string, error) {
+	return s.disk().File(file).TemporaryUploadURL(expires, options)
+}
+
 func (s *Storage) Path(file string) string {
 	return s.disk().File(file).Path()
 }
@@ -67,22 +114,138 @@ func (s *Storage) Append(file string, content []byte) error {
 	return s.disk().File(file).Append(content)
 }
 
+// Copy copies a file on the default disk. Source and destination may name a
+// disk like "s3://exports/a.csv" to copy between disks.
 func (s *Storage) Copy(source string, destination string) error {
-	return s.disk().File(source).Copy(destination)
+	if !isURI(source) && !isURI(destination) {
+		return s.disk().File(source).Copy(destination)
+	}
+
+	return s.transferURI(source, destination, fs.TransferOptions{})
 }
 
+// Move moves a file like Copy.
 func (s *Storage) Move(source string, destination string) error {
-	return s.disk().File(source).Move(destination)
+	if !isURI(source) && !isURI(destination) {
+		return s.disk().File(source).Move(destination)
+	}
+
+	return s.transferURI(source, destination, fs.TransferOptions{Move: true})
+}
+
+// Transfer streams a file between two disks. Within one disk the file is
+// copied natively unless the visibility is overridden.
+func (s *Storage) Transfer(sourceDisk string, source string, destinationDisk string, destination string, options fs.TransferOptions) error {
+	if err := s.checkDisks(sourceDisk, destinationDisk); err != nil {
+		return err
+	}
+
+	src, dst := s.Disk(sourceDisk), s.Disk(destinationDisk)
+
+	// disk.Transfer recognises the disk by its value, which WithContext copies
+	if sourceDisk == destinationDisk {
+		dst = src
+	}
+
+	if sourceDisk == destinationDisk && options.Visibility == 0 && options.Move {
+		return src.Move(source, destination)
+	}
+
+	if sourceDisk == destinationDisk && options.Visibility == 0 {
+		return src.Copy(source, destination)
+	}
+
+	return disk.Transfer(src, source, dst, destination, options)
+}
+
+func (s *Storage) transferURI(source string, destination string, options fs.TransferOptions) error {
+	sourceDisk, source := s.parseURI(source)
+	destinationDisk, destination := s.parseURI(destination)
+
+	return s.Transfer(sourceDisk, source, destinationDisk, destination, options)
+}
+
+// parseURI splits "name://path" into disk name and path. Paths without a
+// disk name belong to the default disk.
+func (s *Storage) parseURI(uri string) (string, string) {
+	if !isURI(uri) {
+		return s.fallback, uri
+	}
+
+	i := strings.Index(uri, "://")
+
+	return uri[:i], uri[i+3:]
+}
+
+func (s *Storage) checkDisks(names ...string) error {
+	for _, name := range names {
+		if s.disks[name] == nil {
+			return &fs.Error{Op: "transfer", Path: name, Kind: fs.ErrNotFound, Err: errors.New("unknown disk")}
+		}
+	}
+
+	return nil
+}
+
+func isURI(file string) bool {
+	return strings.Index(file, "://") > 0
 }
 
 func (s *Storage) Delete(files ...string) error {
 	return s.disk().Delete(files...)
 }
 
+func (s *Storage) Visibility(file string) (fs.Visibility, error) {
+	return s.disk().File(file).Visibility()
+}
+
+func (s *Storage) SetVisibility(file string, visibility fs.Visibility) error {
+	return s.disk().File(file).SetVisibility(visibility)
+}
+
 func (s *Storage) MakeDirectory(dir string, visibility fs.Visibility) error {
 	return s.disk().MakeDirectory(dir, visibility)
 }
 
+// CopyDirectory copies a directory tree. Like Copy the directories may name a disk.
+func (s *Storage) CopyDirectory(source string, destination string, options fs.DirectoryOptions) error {
+	if !isURI(source) && !isURI(destination) {
+		return s.disk().CopyDirectory(source, destination, options)
+	}
+
+	return s.transferDirectory(source, destination, options, false)
+}
+
+func (s *Storage) MoveDirectory(source string, destination string, options fs.DirectoryOptions) error {
+	if !isURI(source) && !isURI(destination) {
+		return s.disk().MoveDirectory(source, destination, options)
+	}
+
+	return s.transferDirectory(source, destination, options, true)
+}
+
+func (s *Storage) transferDirectory(source string, destination string, options fs.DirectoryOptions, move bool) error {
+	sourceDisk, source := s.parseURI(source)
+	destinationDisk, destination := s.parseURI(destination)
+
+	if err := s.checkDisks(sourceDisk, destinationDisk); err != nil {
+		return err
+	}
+
+	src, dst := s.Disk(sourceDisk), s.Disk(destinationDisk)
+
+	switch {
+	case sourceDisk == destinationDisk && move:
+		return src.MoveDirectory(source, destination, options)
+	case sourceDisk == destinationDisk:
+		return src.CopyDirectory(source, destination, options)
+	case move:
+		return disk.MoveDirectory(src, source, dst, destination, options)
+	default:
+		return disk.CopyDirectory(src, source, dst, destination, options)
+	}
+}
+
 func (s *Storage) DeleteDirectory(dir string) error {
 	return s.disk().DeleteDirectory(dir)
 }
@@ -95,6 +258,10 @@ func (s *Storage) Files(dir string) []*fs.File {
 	return s.disk().Files(dir)
 }
 
+func (s *Storage) List(dir string) ([]*fs.File, error) {
+	return s.disk().List(dir)
+}
+
 func (s *Storage) AllFiles(dir string) []*fs.File {
 	return s.disk().AllFiles(dir)
 }
@@ -103,10 +270,34 @@ func (s *Storage) Directories(dir string) []fs.Disk {
 	return s.disk().Directories(dir)
 }
 
+func (s *Storage) ListPage(dir string, token string, limit int) (fs.Page, error) {
+	return s.disk().ListPage(dir, token, limit)
+}
+
+func (s *Storage) ListDirectories(dir string) ([]fs.Disk, error) {
+	return s.disk().ListDirectories(dir)
+}
+
 func (s *Storage) AllDirectories(dir string) []fs.Disk {
 	return s.disk().AllDirectories(dir)
 }
 
+func (s *Storage) Walk(dir string, fn fs.WalkFunc) error {
+	return s.disk().Walk(dir, fn)
+}
+
+func (s *Storage) Glob(pattern string) ([]*fs.File, error) {
+	return s.disk().Glob(pattern)
+}
+
+func (s *Storage) Query(dir string) *fs.Query {
+	return s.disk().Query(dir)
+}
+
+func (s *Storage) Iter(dir string) fs.Iterator {
+	return s.disk().Iter(dir)
+}
+
 func (s *Storage) Prefix(prefix string) fs.Disk {
 	return s.disk().Prefix(prefix)
 }
@@ -115,10 +306,31 @@ func (s *Storage) Cwd() string {
 	return s.disk().Cwd()
 }
 
+func (s *Storage) WithContext(ctx context.Context) fs.Disk {
+	return s.ForContext(ctx)
+}
+
+// ForContext is WithContext keeping the *Storage, so Disk, Default and
+// Transfer stay available.
+func (s *Storage) ForContext(ctx context.Context) *Storage {
+	c := *s
+	c.ctx = ctx
+
+	return &c
+}
+
+func (s *Storage) Context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+
+	return s.ctx
+}
+
 func (s *Storage) Default(name string) {
 	s.fallback = name
 }
 
 func (s *Storage) disk() fs.Disk {
-	return s.disks[s.fallback]
+	return s.Disk(s.fallback)
 }
@@ -1,10 +1,17 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"github.com/evolidev/storage/disk"
 	"github.com/evolidev/storage/fs"
+	"io"
+	"math"
+	"path"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestDisk(t *testing.T) {
@@ -14,6 +21,10 @@ func TestDisk(t *testing.T) {
 	if _, ok := d.(*disk.Local); ok == false {
 		t.Errorf("Wrong disk returned")
 	}
+
+	if d := getStorage().ForContext(context.Background()).Disk("missing"); d != nil {
+		t.Errorf("Expected no disk but got %T", d)
+	}
 }
 
 func TestCreate(t *testing.T) {
@@ -44,6 +55,27 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestPutStream(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/put stream should create file with streamed content", func(t *testing.T) {
+			file := "put_stream/sub/test_stream.txt"
+			err := storage.PutStream(file, strings.NewReader("streamed"), fs.PutOptions{Visibility: fs.PUBLIC})
+			defer clear(storage, file)
+
+			check(t, err, "Failed to write file %s", file)
+			content, err := storage.Get(file)
+			check(t, err, "Failed to read file %s", file)
+			if string(content) != "streamed" {
+				t.Errorf("Content %s does not match %s", content, "streamed")
+			}
+		})
+	}
+}
+
 func TestFile(t *testing.T) {
 	t.Parallel()
 	storage := getStorage()
@@ -76,6 +108,130 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestReadStream(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/read stream should stream file content", func(t *testing.T) {
+			base := "read_stream"
+			d := setup(t, storage, base)
+			defer d()
+			file := base + "/sub/files.txt"
+
+			stream, err := storage.ReadStream(file)
+			check(t, err, "Failed to open file %s", file)
+			defer stream.Close()
+			content, err := io.ReadAll(stream)
+
+			check(t, err, "Failed to read file %s", file)
+			if string(content) != "test" {
+				t.Errorf("Content %s does not match %s", content, "test")
+			}
+		})
+
+		t.Run(name+"/read stream on none existing file should return error", func(t *testing.T) {
+			_, err := storage.ReadStream("read_stream_not_existing.txt")
+
+			if err == nil {
+				t.Errorf("Reading none existing file")
+			}
+		})
+	}
+}
+
+func TestGetRange(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/get range should return the requested bytes", func(t *testing.T) {
+			base := "get_range"
+			d := setup(t, storage, base)
+			defer d()
+			file := base + "/sub/files.txt"
+
+			content, err := storage.GetRange(file, 1, 2)
+
+			check(t, err, "Failed to read file %s", file)
+			if string(content) != "es" {
+				t.Errorf("Content %s does not match %s", content, "es")
+			}
+		})
+
+		t.Run(name+"/get range should stop at the end of the file", func(t *testing.T) {
+			base := "get_range_end"
+			d := setup(t, storage, base)
+			defer d()
+			file := base + "/sub/files.txt"
+
+			content, err := storage.GetRange(file, 2, 10)
+
+			check(t, err, "Failed to read file %s", file)
+			if string(content) != "st" {
+				t.Errorf("Content %s does not match %s", content, "st")
+			}
+		})
+
+		t.Run(name+"/get range should read to the end with a large length", func(t *testing.T) {
+			base := "get_range_large"
+			d := setup(t, storage, base)
+			defer d()
+			file := base + "/sub/files.txt"
+
+			content, err := storage.GetRange(file, 1, math.MaxInt64/2)
+
+			check(t, err, "Failed to read file %s", file)
+			if string(content) != "est" {
+				t.Errorf("Content %s does not match %s", content, "est")
+			}
+		})
+
+		t.Run(name+"/file should be readable as io.ReaderAt", func(t *testing.T) {
+			base := "read_at"
+			d := setup(t, storage, base)
+			defer d()
+			file := storage.File(base + "/sub/files.txt")
+
+			content, err := io.ReadAll(io.NewSectionReader(file, 1, 3))
+
+			check(t, err, "Failed to read file %s", file.Name())
+			if string(content) != "est" {
+				t.Errorf("Content %s does not match %s", content, "est")
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/missing files should return not found errors", func(t *testing.T) {
+			file := "errors/not_existing.txt"
+
+			_, getErr := storage.Get(file)
+			_, streamErr := storage.ReadStream(file)
+			_, rangeErr := storage.GetRange(file, 0, 1)
+
+			for _, err := range []error{getErr, streamErr, rangeErr} {
+				if !errors.Is(err, fs.ErrNotFound) {
+					t.Errorf("Expected not found error but got %s", err)
+				}
+
+				var e *fs.Error
+				if !errors.As(err, &e) || e.Path != file {
+					t.Errorf("Expected *fs.Error for %s but got %s", file, err)
+				}
+			}
+		})
+	}
+}
+
 func TestExists(t *testing.T) {
 	t.Parallel()
 	testStorage := getStorage()
@@ -200,6 +356,634 @@ func TestMetadata(t *testing.T) {
 	}
 }
 
+func TestStat(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/stat should return attributes", func(t *testing.T) {
+			base := "stat"
+			d := setup(t, storage, base)
+			defer d()
+			file := base + "/sub/files.txt"
+
+			a, err := storage.Stat(file)
+
+			check(t, err, "Failed to stat file %s", file)
+			if a.Size != 4 || a.LastModified == 0 {
+				t.Errorf("Wrong attributes %+v", a)
+			}
+		})
+
+		t.Run(name+"/stat should report directories", func(t *testing.T) {
+			base := "stat_dir"
+			d := setup(t, storage, base)
+			defer d()
+
+			a, err := storage.Stat(base + "/sub")
+
+			check(t, err, "Failed to stat directory %s", base+"/sub")
+			if !a.IsDir {
+				t.Errorf("%s is not reported as directory", base+"/sub")
+			}
+		})
+
+		t.Run(name+"/stat should return an etag", func(t *testing.T) {
+			base := "stat_etag"
+			d := setup(t, storage, base)
+			defer d()
+			file := base + "/sub/files.txt"
+
+			a, err := storage.Stat(file)
+
+			check(t, err, "Failed to stat file %s", file)
+			if a.ETag == "" || a.IsDir {
+				t.Errorf("Wrong attributes %+v", a)
+			}
+		})
+
+		t.Run(name+"/stat should return error for missing files", func(t *testing.T) {
+			_, err := storage.Stat("stat_not_existing.txt")
+
+			if !errors.Is(err, fs.ErrNotFound) {
+				t.Errorf("Expected not found error but got %s", err)
+			}
+		})
+
+		t.Run(name+"/list should return files and directories", func(t *testing.T) {
+			base := "list_errors"
+			d := setup(t, storage, base)
+			defer d()
+
+			files, err := storage.List(base)
+			check(t, err, "Failed to list %s", base)
+			dirs, err := storage.ListDirectories(base)
+			check(t, err, "Failed to list %s", base)
+
+			if len(files) != 1 {
+				t.Errorf("Expected count of %d does not match current %d", 1, len(files))
+			}
+			if len(dirs) != 3 {
+				t.Errorf("Expected count of %d does not match current %d", 3, len(dirs))
+			}
+		})
+	}
+}
+
+func TestListPage(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/list page should page through all entries", func(t *testing.T) {
+			base := "list_page"
+			d := setup(t, storage, base)
+			defer d()
+			entries := make(map[string]bool)
+			token := ""
+
+			for i := 0; i < 10; i++ {
+				page, err := storage.ListPage(base, token, 3)
+				check(t, err, "Failed to list page of %s", base)
+
+				if i == 0 && page.Next == "" {
+					t.Errorf("First page should have a next token")
+				}
+
+				for _, file := range page.Files {
+					entries[file.Name()] = true
+				}
+
+				for _, dir := range page.Directories {
+					entries[path.Base(dir.Cwd())] = true
+				}
+
+				token = page.Next
+				if token == "" {
+					break
+				}
+			}
+
+			if token != "" {
+				t.Errorf("Paging did not end")
+			}
+
+			if len(entries) != 4 || !entries["files.txt"] || !entries["sub"] || !entries["sub2"] || !entries["empty"] {
+				t.Errorf("Unexpected entries %v", entries)
+			}
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/walk should visit files at any depth", func(t *testing.T) {
+			base := "walk"
+			d := setup(t, storage, base)
+			defer d()
+			deep := base + "/sub/sub/sub/sub/files.txt"
+			createFile(t, storage, deep)
+			defer clear(storage, deep)
+			files := make([]string, 0)
+
+			err := storage.Walk(base, func(entry fs.Entry) error {
+				if !entry.IsDir() {
+					files = append(files, entry.Path)
+				}
+
+				return nil
+			})
+
+			check(t, err, "Failed to walk %s", base)
+			sort.Strings(files)
+			expected := "walk/files.txt,walk/sub/files.txt,walk/sub/sub/files.txt,walk/sub/sub/sub/sub/files.txt,walk/sub2/files.txt"
+			if strings.Join(files, ",") != expected {
+				t.Errorf("Unexpected files %v", files)
+			}
+		})
+
+		t.Run(name+"/walk should skip directories", func(t *testing.T) {
+			base := "walk_skip"
+			d := setup(t, storage, base)
+			defer d()
+			files := make([]string, 0)
+
+			err := storage.Walk(base, func(entry fs.Entry) error {
+				if entry.IsDir() && entry.Name() == "sub" {
+					return fs.SkipDir
+				}
+
+				if !entry.IsDir() {
+					files = append(files, entry.Path)
+				}
+
+				return nil
+			})
+
+			check(t, err, "Failed to walk %s", base)
+			sort.Strings(files)
+			if strings.Join(files, ",") != "walk_skip/files.txt,walk_skip/sub2/files.txt" {
+				t.Errorf("Unexpected files %v", files)
+			}
+		})
+
+		t.Run(name+"/walk should stop on error", func(t *testing.T) {
+			base := "walk_error"
+			d := setup(t, storage, base)
+			defer d()
+			stop := errors.New("stop")
+
+			if err := storage.Walk(base, func(entry fs.Entry) error { return stop }); err != stop {
+				t.Errorf("Expected error %s but got %s", stop, err)
+			}
+		})
+
+		t.Run(name+"/iter should stream all entries", func(t *testing.T) {
+			base := "iter"
+			d := setup(t, storage, base)
+			defer d()
+			it := storage.Iter(base)
+			defer it.Close()
+			entries := 0
+
+			for it.Next() {
+				entries++
+			}
+
+			check(t, it.Err(), "Failed to iterate %s", base)
+			// 4 files, 4 directories including empty
+			if entries != 8 {
+				t.Errorf("Expected count of %d does not match current %d", 8, entries)
+			}
+		})
+
+		t.Run(name+"/iter should return error for cancelled context", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			it := storage.WithContext(ctx).Iter("")
+
+			if it.Next() || !errors.Is(it.Err(), context.Canceled) {
+				t.Errorf("Expected cancelled error but got %s", it.Err())
+			}
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/query should filter files", func(t *testing.T) {
+			base := "query"
+			defer storage.DeleteDirectory(base)
+			storage.Put(base+"/a.csv", []byte("abc"), fs.PUBLIC)
+			storage.Put(base+"/b.CSV", []byte("abcdefghij"), fs.PUBLIC)
+			storage.Put(base+"/c.txt", []byte("abcdefghij"), fs.PUBLIC)
+			storage.PutWithOptions(base+"/sub/d.csv", []byte("abcdefghijklmnopqrst"), fs.PutOptions{
+				Metadata: map[string]string{"owner": "test"},
+			})
+			later := time.Now().Add(time.Hour)
+			names := func(files []*fs.File, err error) string {
+				t.Helper()
+				check(t, err, "Failed to query %s", base)
+				r := make([]string, 0, len(files))
+				for _, file := range files {
+					r = append(r, file.Name())
+				}
+				sort.Strings(r)
+
+				return strings.Join(r, ",")
+			}
+
+			cases := map[string]*fs.Query{
+				"a.csv,b.CSV":       storage.Query(base).Extension("csv"),
+				"a.csv,b.CSV,d.csv": storage.Query(base).Recursive().Extension(".csv"),
+				"b.CSV,d.csv":       storage.Query(base).Recursive().Extension("csv").LargerThan(5),
+				"a.csv":             storage.Query(base).SmallerThan(5),
+				"d.csv":             storage.Query(base).Recursive().WithMetadata("owner", "test"),
+				"":                  storage.Query(base).Recursive().ModifiedAfter(later),
+				"a.csv,b.CSV,c.txt": storage.Query(base).ModifiedBefore(later),
+				"c.txt":             storage.Query(base).Where(func(a fs.Attributes) bool { return a.Size == 10 }).Extension("txt"),
+			}
+
+			for expected, query := range cases {
+				if r := names(query.Get()); r != expected {
+					t.Errorf("Expected %q but got %q", expected, r)
+				}
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/listed files should keep attributes until refreshed", func(t *testing.T) {
+			base := "refresh"
+			defer storage.DeleteDirectory(base)
+			file := base + "/files.txt"
+			createFile(t, storage, file)
+
+			files, err := storage.List(base)
+			check(t, err, "Failed to list %s", base)
+
+			if len(files) != 1 || files[0].Size() != 4 {
+				t.Fatalf("Unexpected listing %v", files)
+			}
+
+			storage.Put(file, []byte("changed"), fs.PUBLIC)
+
+			if files[0].Size() != 4 {
+				t.Errorf("Expected cached size %d but got %d", 4, files[0].Size())
+			}
+
+			a, err := files[0].Refresh()
+			check(t, err, "Failed to refresh %s", file)
+
+			if a.Size != 7 || files[0].Size() != 7 {
+				t.Errorf("Expected refreshed size %d but got %d", 7, a.Size)
+			}
+		})
+	}
+}
+
+func TestCopyOntoItself(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage.ForContext(context.Background())
+		storage.Default(name)
+		t.Run(name+"/copy and move onto itself should keep the file", func(t *testing.T) {
+			base := "copy_itself"
+			defer storage.DeleteDirectory(base)
+			file := base + "/files.txt"
+			createFile(t, storage, file)
+
+			errs := []error{
+				storage.Copy(file, file),
+				storage.Move(file, "/"+file),
+				storage.Copy(name+"://"+file, name+"://"+file),
+				storage.Move(name+"://"+file, name+"://"+file),
+				disk.Transfer(storage.Disk(name), file, storage.Disk(name), file, fs.TransferOptions{}),
+				storage.Transfer(name, file, name, file, fs.TransferOptions{Visibility: fs.PRIVATE}),
+			}
+
+			for _, err := range errs {
+				check(t, err, "Failed to copy %s onto itself", file)
+			}
+
+			if content, _ := storage.Get(file); string(content) != "test" {
+				t.Errorf("Content %s does not match %s", content, "test")
+			}
+
+			if v, _ := storage.Visibility(file); v != fs.PRIVATE {
+				t.Errorf("Expected private visibility but got %o", v)
+			}
+
+			if err := storage.Copy(base+"/missing.txt", base+"/missing.txt"); !errors.Is(err, fs.ErrNotFound) {
+				t.Errorf("Expected not found error but got %v", err)
+			}
+		})
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	t.Parallel()
+	storage := getStorage()
+	defer storage.Disk("local").DeleteDirectory("transfer")
+	file := "transfer/files.txt"
+	storage.Disk("local").PutWithOptions(file, []byte("test"), fs.PutOptions{
+		Visibility: fs.PRIVATE,
+		Metadata:   map[string]string{"owner": "test"},
+	})
+
+	err := storage.Transfer("local", file, "s3", "exports/files.txt", fs.TransferOptions{})
+	check(t, err, "Failed to transfer %s", file)
+
+	s3 := storage.Disk("s3")
+	content, _ := s3.Get("exports/files.txt")
+	a, _ := s3.Stat("exports/files.txt")
+	visibility, _ := s3.Visibility("exports/files.txt")
+
+	if string(content) != "test" || a.Metadata["owner"] != "test" || visibility != fs.PRIVATE {
+		t.Errorf("Unexpected transfer %s %+v %v", content, a, visibility)
+	}
+
+	err = storage.Move("s3://exports/files.txt", "memory://moved/files.txt")
+	check(t, err, "Failed to move %s", "exports/files.txt")
+
+	if s3.Exists("exports/files.txt") || !storage.Disk("memory").Exists("moved/files.txt") {
+		t.Errorf("File was not moved between disks")
+	}
+
+	err = storage.Copy("local://"+file, "transfer/copy.txt")
+	check(t, err, "Failed to copy %s", file)
+
+	if !storage.Disk(storage.fallback).Exists("transfer/copy.txt") || !storage.Disk("local").Exists(file) {
+		t.Errorf("File was not copied to the default disk")
+	}
+
+	if err := storage.Copy("ftp://"+file, "copy.txt"); !errors.Is(err, fs.ErrNotFound) {
+		t.Errorf("Expected error for unknown disk but got %v", err)
+	}
+}
+
+func TestCopyDirectory(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	paths := func(storage fs.Disk, dir string) string {
+		r := make([]string, 0)
+		storage.Walk(dir, func(entry fs.Entry) error {
+			r = append(r, strings.TrimPrefix(entry.Path, dir))
+
+			return nil
+		})
+		sort.Strings(r)
+
+		return strings.Join(r, ",")
+	}
+
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/copy and move directory should keep the tree", func(t *testing.T) {
+			base := "copy_directory"
+			d := setup(t, storage, base+"/source")
+			defer storage.DeleteDirectory(base)
+			defer d()
+			expected := paths(storage, base+"/source")
+
+			err := storage.CopyDirectory(base+"/source", base+"/copy", fs.DirectoryOptions{Parallelism: 4})
+			check(t, err, "Failed to copy directory %s", base)
+
+			if r := paths(storage, base+"/copy"); r != expected {
+				t.Errorf("Expected %s but got %s", expected, r)
+			}
+
+			err = storage.MoveDirectory(base+"/copy", base+"/moved/here", fs.DirectoryOptions{Parallelism: 4})
+			check(t, err, "Failed to move directory %s", base)
+
+			if r := paths(storage, base+"/moved/here"); r != expected {
+				t.Errorf("Expected %s but got %s", expected, r)
+			}
+
+			if storage.Exists(base + "/copy/files.txt") {
+				t.Errorf("Directory still exists %s", base+"/copy")
+			}
+		})
+
+		t.Run(name+"/copy directory should follow the overwrite policy", func(t *testing.T) {
+			base := "copy_directory_overwrite"
+			d := setup(t, storage, base+"/source")
+			defer storage.DeleteDirectory(base)
+			defer d()
+			storage.Put(base+"/target/files.txt", []byte("keep"), fs.PUBLIC)
+
+			err := storage.CopyDirectory(base+"/source", base+"/target", fs.DirectoryOptions{Overwrite: fs.OverwriteSkip})
+			check(t, err, "Failed to copy directory %s", base)
+
+			if content, _ := storage.Get(base + "/target/files.txt"); string(content) != "keep" {
+				t.Errorf("Existing file was overwritten")
+			}
+
+			err = storage.MoveDirectory(base+"/source", base+"/target", fs.DirectoryOptions{Overwrite: fs.OverwriteFail})
+
+			if !errors.Is(err, fs.ErrExists) || len(err.(fs.Errors)) != 4 {
+				t.Errorf("Expected errors for existing files but got %v", err)
+			}
+
+			if !storage.Exists(base + "/source/files.txt") {
+				t.Errorf("Source should be kept after errors")
+			}
+		})
+
+		t.Run(name+"/copy directory into itself should return error", func(t *testing.T) {
+			err := storage.CopyDirectory("self", "self/sub", fs.DirectoryOptions{})
+
+			if !errors.Is(err, fs.ErrExists) {
+				t.Errorf("Expected error but got %v", err)
+			}
+		})
+	}
+
+	t.Run("copy directory between disks", func(t *testing.T) {
+		storage := getStorage()
+		local := storage.Disk("local")
+		d := setup(t, local, "copy_directory_disks")
+		defer local.DeleteDirectory("copy_directory_disks")
+		defer d()
+
+		err := storage.MoveDirectory("local://copy_directory_disks", "memory://moved", fs.DirectoryOptions{Parallelism: 2})
+		check(t, err, "Failed to move directory %s", "copy_directory_disks")
+
+		if r := paths(storage.Disk("memory"), "moved"); r != "/empty,/files.txt,/sub,/sub/files.txt,/sub/sub,/sub/sub/files.txt,/sub2,/sub2/files.txt" {
+			t.Errorf("Unexpected tree %s", r)
+		}
+
+		if local.Exists("copy_directory_disks") {
+			t.Errorf("Source directory still exists")
+		}
+	})
+}
+
+func TestPutWithOptions(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/put with options should store headers and metadata", func(t *testing.T) {
+			base := "put_options"
+			defer storage.DeleteDirectory(base)
+			file := base + "/report.pdf"
+
+			err := storage.PutWithOptions(file, []byte("test"), fs.PutOptions{
+				Visibility:         fs.PUBLIC,
+				ContentType:        "application/pdf",
+				CacheControl:       "max-age=3600",
+				ContentDisposition: "attachment",
+				ContentEncoding:    "identity",
+				Metadata:           map[string]string{"owner": "test"},
+			})
+			check(t, err, "Failed to put file %s", file)
+
+			a, err := storage.Stat(file)
+			check(t, err, "Failed to stat file %s", file)
+
+			if a.ContentType != "application/pdf" || a.CacheControl != "max-age=3600" ||
+				a.ContentDisposition != "attachment" || a.ContentEncoding != "identity" {
+				t.Errorf("Wrong attributes %+v", a)
+			}
+
+			if a.Metadata["owner"] != "test" {
+				t.Errorf("Metadata not stored %+v", a.Metadata)
+			}
+		})
+
+		t.Run(name+"/append and prepend should keep options", func(t *testing.T) {
+			base := "put_options_append"
+			defer storage.DeleteDirectory(base)
+			file := base + "/files.txt"
+
+			storage.PutWithOptions(file, []byte("test"), fs.PutOptions{
+				ContentType:  "text/csv",
+				CacheControl: "no-cache",
+				Metadata:     map[string]string{"owner": "test"},
+			})
+			check(t, storage.Append(file, []byte(" append")), "Failed to append to %s", file)
+			check(t, storage.Prepend(file, []byte("prepend ")), "Failed to prepend to %s", file)
+			a, _ := storage.Stat(file)
+
+			if a.ContentType != "text/csv" || a.CacheControl != "no-cache" || a.Metadata["owner"] != "test" {
+				t.Errorf("Options lost %+v", a)
+			}
+		})
+
+		t.Run(name+"/put should reset previous options", func(t *testing.T) {
+			base := "put_options_reset"
+			defer storage.DeleteDirectory(base)
+			file := base + "/files.txt"
+
+			storage.PutWithOptions(file, []byte("test"), fs.PutOptions{CacheControl: "no-cache"})
+			storage.Put(file, []byte("test"), fs.PUBLIC)
+
+			a, err := storage.Stat(file)
+			check(t, err, "Failed to stat file %s", file)
+
+			if a.CacheControl != "" {
+				t.Errorf("Cache control %s should be reset", a.CacheControl)
+			}
+		})
+	}
+}
+
+func TestVisibility(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/visibility should be stored on put", func(t *testing.T) {
+			base := "visibility"
+			defer storage.DeleteDirectory(base)
+			file := base + "/files.txt"
+
+			storage.Put(file, []byte("test"), fs.PRIVATE)
+			v, err := storage.Visibility(file)
+
+			check(t, err, "Failed to get visibility of %s", file)
+			if v != fs.PRIVATE {
+				t.Errorf("Expected private visibility but got %o", v)
+			}
+		})
+
+		t.Run(name+"/stat should return the visibility", func(t *testing.T) {
+			base := "visibility_stat"
+			defer storage.DeleteDirectory(base)
+			file := base + "/files.txt"
+
+			storage.Put(file, []byte("test"), fs.PRIVATE)
+			a, err := storage.Stat(file)
+
+			// s3 does not fetch the acl on stat
+			check(t, err, "Failed to stat %s", file)
+			if a.Visibility != fs.PRIVATE && a.Visibility != 0 {
+				t.Errorf("Expected private visibility but got %o", a.Visibility)
+			}
+		})
+
+		t.Run(name+"/set visibility should change the visibility", func(t *testing.T) {
+			base := "set_visibility"
+			defer storage.DeleteDirectory(base)
+			file := base + "/files.txt"
+
+			storage.Put(file, []byte("test"), fs.PRIVATE)
+			err := storage.SetVisibility(file, fs.PUBLIC)
+			check(t, err, "Failed to set visibility of %s", file)
+
+			v, err := storage.Visibility(file)
+
+			check(t, err, "Failed to get visibility of %s", file)
+			if v != fs.PUBLIC {
+				t.Errorf("Expected public visibility but got %o", v)
+			}
+		})
+
+		t.Run(name+"/append should keep the visibility", func(t *testing.T) {
+			base := "append_visibility"
+			defer storage.DeleteDirectory(base)
+			file := base + "/files.txt"
+
+			storage.Put(file, []byte("test"), fs.PRIVATE)
+			storage.Append(file, []byte("test"))
+			v, _ := storage.Visibility(file)
+
+			if v != fs.PRIVATE {
+				t.Errorf("Expected private visibility but got %o", v)
+			}
+		})
+
+		t.Run(name+"/visibility should return error for missing files", func(t *testing.T) {
+			_, err := storage.Visibility("visibility_not_existing.txt")
+
+			if !errors.Is(err, fs.ErrNotFound) {
+				t.Errorf("Expected not found error but got %s", err)
+			}
+		})
+	}
+}
+
 func TestPath(t *testing.T) {
 	t.Parallel()
 	testStorage := getStorage()
@@ -325,6 +1109,35 @@ func TestCopy(t *testing.T) {
 			}
 		})
 
+		t.Run(name+"/copy and move should keep visibility and metadata", func(t *testing.T) {
+			base := "copy_attributes"
+			defer storage.DeleteDirectory(base)
+			file := base + "/files.txt"
+			storage.PutWithOptions(file, []byte("test"), fs.PutOptions{
+				Visibility:   fs.PRIVATE,
+				CacheControl: "no-cache",
+				Metadata:     map[string]string{"owner": "test"},
+			})
+
+			for _, target := range []string{base + "/copy/files.txt", base + "/move/files.txt"} {
+				var err error
+
+				if strings.Contains(target, "move") {
+					err = storage.Move(file, target)
+				} else {
+					err = storage.Copy(file, target)
+				}
+
+				check(t, err, "Failed to copy file %s", target)
+				a, _ := storage.Stat(target)
+				visibility, _ := storage.Visibility(target)
+
+				if visibility != fs.PRIVATE || a.CacheControl != "no-cache" || a.Metadata["owner"] != "test" {
+					t.Errorf("Lost attributes of %s: %v %+v", target, visibility, a)
+				}
+			}
+		})
+
 		t.Run(name+"/copy on none existing file should return error", func(t *testing.T) {
 			base := "copy"
 			d := setup(t, storage, base)
@@ -430,6 +1243,44 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+func TestWithContext(t *testing.T) {
+	t.Parallel()
+	testStorage := getStorage()
+	for name, _ := range testStorage.disks {
+		storage := testStorage
+		storage.Default(name)
+		t.Run(name+"/cancelled context should stop delete directory", func(t *testing.T) {
+			base := "context_delete"
+			d := setup(t, storage, base)
+			defer d()
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := storage.WithContext(ctx).DeleteDirectory(base)
+
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected context error but got %s", err)
+			}
+			if !storage.Exists(base + "/sub/files.txt") {
+				t.Errorf("File got deleted")
+			}
+		})
+
+		t.Run(name+"/context should be passed to prefixed disks", func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), contextKey("key"), name)
+
+			prefixed := storage.WithContext(ctx).Prefix("context")
+
+			if prefixed.Context().Value(contextKey("key")) != name {
+				t.Errorf("context got lost")
+			}
+			if storage.Context().Value(contextKey("key")) != nil {
+				t.Errorf("original context got adjusted")
+			}
+		})
+	}
+}
+
 func TestMakeDirectory(t *testing.T) {
 	t.Parallel()
 	testStorage := getStorage()
@@ -687,3 +1538,5 @@ func getStorage() *Storage {
 
 	return New(adapters)
 }
+
+type contextKey string
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/evolidev/storage/disk"
+	"github.com/evolidev/storage/fs"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+type SyncOptions struct {
+	// Checksum compares files by their SHA-256 checksum instead of size and
+	// modification time.
+	Checksum bool
+	// Delete removes files from the destination which are missing in the source.
+	Delete bool
+	// DryRun only reports what would be done.
+	DryRun bool
+	// Include and Exclude are fs.Match patterns on the paths relative to the
+	// synced directories. Excluded files are neither copied nor deleted.
+	Include []string
+	Exclude []string
+}
+
+// SyncReport lists the paths relative to the synced directories.
+type SyncReport struct {
+	Created []string
+	Updated []string
+	Deleted []string
+	Skipped []string
+}
+
+// Sync mirrors a directory to another directory, which may be on another disk.
+// It copies new files and files that changed since the last sync. Failures are
+// returned together as fs.Errors once all files were tried.
+func Sync(source fs.Disk, sourceDir string, destination fs.Disk, destinationDir string, options SyncOptions) (SyncReport, error) {
+	s := &syncer{options: options}
+	sourceDir, destinationDir = strings.Trim(sourceDir, "/"), strings.Trim(destinationDir, "/")
+	sourceFiles, err := s.files(source, sourceDir)
+
+	if err != nil {
+		return s.report, err
+	}
+
+	destinationFiles, err := s.files(destination, destinationDir)
+
+	if err != nil && !errors.Is(err, fs.ErrNotFound) {
+		return s.report, err
+	}
+
+	for _, name := range sortedKeys(sourceFiles) {
+		target := path.Join(destinationDir, name)
+		existing, ok := destinationFiles[name]
+
+		if !ok {
+			s.report.Created = append(s.report.Created, name)
+		} else if s.changed(sourceFiles[name], existing) {
+			s.report.Updated = append(s.report.Updated, name)
+		} else {
+			s.report.Skipped = append(s.report.Skipped, name)
+
+			continue
+		}
+
+		if !options.DryRun {
+			s.fail(disk.Transfer(source, path.Join(sourceDir, name), destination, target, fs.TransferOptions{}))
+		}
+	}
+
+	for _, name := range sortedKeys(destinationFiles) {
+		if _, ok := sourceFiles[name]; ok || !options.Delete {
+			continue
+		}
+
+		s.report.Deleted = append(s.report.Deleted, name)
+
+		if !options.DryRun {
+			s.fail(destination.Delete(path.Join(destinationDir, name)))
+		}
+	}
+
+	if len(s.errors) > 0 {
+		return s.report, s.errors
+	}
+
+	return s.report, nil
+}
+
+type syncer struct {
+	options SyncOptions
+	report  SyncReport
+	errors  fs.Errors
+}
+
+// files collects the included files below dir by their relative path.
+func (s *syncer) files(d fs.Disk, dir string) (map[string]*fs.File, error) {
+	files := make(map[string]*fs.File)
+
+	err := d.Walk(dir, func(entry fs.Entry) error {
+		name := strings.TrimPrefix(strings.TrimPrefix(entry.Path, dir), "/")
+
+		if !entry.IsDir() && s.included(name) {
+			files[name] = entry.File
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+func (s *syncer) included(name string) bool {
+	for _, pattern := range s.options.Exclude {
+		if ok, _ := fs.Match(pattern, name); ok {
+			return false
+		}
+	}
+
+	for _, pattern := range s.options.Include {
+		if ok, _ := fs.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return len(s.options.Include) == 0
+}
+
+// changed compares size and modification time, a destination newer than the
+// source is up to date. With Checksum the contents are compared.
+func (s *syncer) changed(source *fs.File, destination *fs.File) bool {
+	if source.Size() != destination.Size() {
+		return true
+	}
+
+	if !s.options.Checksum {
+		return source.LastModified() > destination.LastModified()
+	}
+
+	a, err := checksum(source)
+
+	if err != nil {
+		s.fail(err)
+
+		return false
+	}
+
+	b, err := checksum(destination)
+
+	if err != nil {
+		s.fail(err)
+
+		return false
+	}
+
+	return a != b
+}
+
+func (s *syncer) fail(err error) {
+	if err != nil {
+		s.errors = append(s.errors, err)
+	}
+}
+
+// checksum returns the SHA-256 checksum the disk knows or hashes the content.
+func checksum(file *fs.File) (string, error) {
+	a, err := file.Refresh()
+
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(a.Checksum, "sha256:") {
+		return a.Checksum, nil
+	}
+
+	content, err := file.ReadStream()
+
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	hash := sha256.New()
+
+	if _, err = io.Copy(hash, content); err != nil {
+		return "", err
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func sortedKeys(files map[string]*fs.File) []string {
+	keys := make([]string, 0, len(files))
+
+	for k := range files {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
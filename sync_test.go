@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"github.com/evolidev/storage/disk"
+	"github.com/evolidev/storage/fs"
+	"strings"
+	"testing"
+)
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+	local := disk.NewLocal(disk.LocalConfig{})
+	memory := disk.NewMemory(disk.MemoryConfig{})
+	defer local.DeleteDirectory("sync")
+	local.Put("sync/a.csv", []byte("abc"), fs.PUBLIC)
+	local.Put("sync/sub/b.csv", []byte("abc"), fs.PUBLIC)
+	local.Put("sync/skip.tmp", []byte("abc"), fs.PUBLIC)
+	memory.Put("backup/old.csv", []byte("abc"), fs.PUBLIC)
+	memory.Put("backup/keep.tmp", []byte("abc"), fs.PUBLIC)
+	options := SyncOptions{Delete: true, DryRun: true, Exclude: []string{"**/*.tmp"}}
+	paths := func(paths []string) string {
+		return strings.Join(paths, ",")
+	}
+
+	report, err := Sync(local, "sync", memory, "backup", options)
+	check(t, err, "Failed to sync %s", "sync")
+
+	if paths(report.Created) != "a.csv,sub/b.csv" || paths(report.Deleted) != "old.csv" || memory.Exists("backup/a.csv") || !memory.Exists("backup/old.csv") {
+		t.Errorf("Unexpected dry run %+v", report)
+	}
+
+	options.DryRun = false
+	report, err = Sync(local, "sync", memory, "backup", options)
+	check(t, err, "Failed to sync %s", "sync")
+
+	if content, _ := memory.Get("backup/sub/b.csv"); string(content) != "abc" || memory.Exists("backup/old.csv") || !memory.Exists("backup/keep.tmp") {
+		t.Errorf("Unexpected sync %+v", report)
+	}
+
+	report, err = Sync(local, "sync", memory, "backup", options)
+	check(t, err, "Failed to sync %s", "sync")
+
+	if paths(report.Skipped) != "a.csv,sub/b.csv" || len(report.Created)+len(report.Updated)+len(report.Deleted) != 0 {
+		t.Errorf("Expected unchanged files to be skipped %+v", report)
+	}
+
+	local.Put("sync/a.csv", []byte("xyz"), fs.PUBLIC)
+	options.Checksum = true
+	report, err = Sync(local, "sync", memory, "backup", options)
+	check(t, err, "Failed to sync %s", "sync")
+
+	if content, _ := memory.Get("backup/a.csv"); paths(report.Updated) != "a.csv" || string(content) != "xyz" {
+		t.Errorf("Expected changed file to be updated %+v", report)
+	}
+
+	report, err = Sync(memory, "backup", local, "sync_missing", SyncOptions{Include: []string{"*.csv"}})
+	defer local.DeleteDirectory("sync_missing")
+	check(t, err, "Failed to sync %s", "backup")
+
+	if paths(report.Created) != "a.csv" || !local.Exists("sync_missing/a.csv") {
+		t.Errorf("Expected sync into missing directory %+v", report)
+	}
+}